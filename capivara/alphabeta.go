@@ -2,140 +2,405 @@ package main
 
 import (
 	"fmt"
-	"time"
 )
 
+type alphaBetaState struct {
+	nodes           int64
+	showSearch      bool
+	control         *searchControl // optional
+	cancelled       bool
+	singleChildren  bool
+	moves           *movePool
+	tt              *transpositionTable // optional
+	ttHits          int64
+	seldepth        int                      // deepest ply reached, including quiescence
+	currMove        func(m move, number int) // optional: report root move being searched
+	path            []uint64                 // zobrist keys for game history plus search path
+	pv              *pvTable
+	nodeLimit       int64         // optional: stop after visiting this many nodes
+	searchMoves     []move        // optional: restrict root moves
+	excludeMoves    []move        // optional: root moves skipped, like previous MultiPV lines
+	order           *moveOrdering // optional: killers and history
+	stats           orderStats
+	researches      int64 // pvs re-searches after null window fail high
+	aspirationFails int64 // root re-searches after aspiration window failure
+	prune           pruningFlags
+	pruneStats      pruneStats
+	lineExtensions  int // extensions along current line
+}
+
+// pvsWindow is the width of the null window used to scout non-PV children.
+const pvsWindow = 1
+
+// rootAlphaBeta returns score, principal variation and comment.
+func rootAlphaBeta(ab *alphaBetaState, b board, depth int, addChildren bool) (int, variation, string) {
+	return rootAlphaBetaWindow(ab, b, depth, alphabetaMin, alphabetaMax, addChildren)
+}
+
+// aspiration windows
+//
+// https://www.chessprogramming.org/Aspiration_Windows
+//
+// iterative deepening scores are usually close to the previous
+// iteration score, thus the root is searched with a narrow window
+// around it. on fail low or fail high, the failing side of the
+// window is widened until the score falls inside.
+
 const (
-	alphabetaMin = -1000.0
-	alphabetaMax = 1000.0
+	aspirationMinDepth = 3  // previous iteration depth required for aspiration
+	aspirationWindow   = 50 // initial half width, in centipawns
 )
 
-type alphaBetaState struct {
-	nodes          int64
-	showSearch     bool
-	deadline       time.Time
-	cancelled      bool
-	singleChildren bool
-	children       *boardPool
+func aspirationSearch(ab *alphaBetaState, b board, depth int, previous int, addChildren bool) (int, variation, string) {
+	if isMateScore(previous) {
+		return rootAlphaBeta(ab, b, depth, addChildren)
+	}
+
+	delta := aspirationWindow
+	alpha := max(previous-delta, alphabetaMin)
+	beta := min(previous+delta, alphabetaMax)
+
+	for {
+		score, pv, comment := rootAlphaBetaWindow(ab, b, depth, alpha, beta, addChildren)
+		if ab.cancelled || ab.singleChildren || comment != "" {
+			return score, pv, comment
+		}
+		switch {
+		case score <= alpha && alpha > alphabetaMin:
+			ab.aspirationFails++
+			delta *= 4
+			alpha = max(score-delta, alphabetaMin)
+		case score >= beta && beta < alphabetaMax:
+			ab.aspirationFails++
+			delta *= 4
+			beta = min(score+delta, alphabetaMax)
+		default:
+			return score, pv, comment
+		}
+	}
 }
 
-func rootAlphaBeta(ab *alphaBetaState, b board, depth int, addChildren bool) (float32, move, string) {
+// rootAlphaBetaWindow searches root with window alpha..beta.
+// score <= alpha means fail low, score >= beta means fail high:
+// either way the caller must re-search with a wider window.
+func rootAlphaBetaWindow(ab *alphaBetaState, b board, depth int, alpha, beta int, addChildren bool) (int, variation, string) {
+	if ab.pv == nil {
+		ab.pv = &pvTable{}
+	}
 	if depth < 1 {
-		return relativeMaterial(ab.children, b, addChildren), nullMove, "invalid-depth"
+		return quiescence(ab, &b, alphabetaMin, alphabetaMax, 0, addChildren), nil, "invalid-depth"
 	}
 	if b.otherKingInCheck() {
-		return alphabetaMax, nullMove, "checkmate"
+		return mateIn(0), nil, "checkmate"
 	}
-	children := ab.children
-	countChildren := b.generateChildren(children)
-	if countChildren == 0 {
+	moves := ab.moves
+	countMoves := b.generateMoves(moves)
+	if countMoves == 0 {
 		if b.kingInCheck() {
-			return alphabetaMin, nullMove, "checkmated" // checkmated
+			return matedIn(0), nil, "checkmated" // checkmated
 		}
-		return 0, nullMove, "draw"
+		return 0, nil, "draw"
 	}
 
-	ab.nodes += int64(countChildren)
+	countMoves = keepSearchMoves(moves, countMoves, ab.searchMoves)
+	countMoves = dropExcludedMoves(moves, countMoves, ab.excludeMoves)
+	if countMoves == 0 {
+		return 0, nil, "" // every move already reported in previous lines
+	}
+	defer moves.drop(countMoves)
+
+	ab.nodes += int64(countMoves)
 
-	firstChild := len(children.pool) - countChildren
-	if countChildren == 1 {
+	rootMoves := moves.last(countMoves)
+	if countMoves == 1 && len(ab.excludeMoves) == 0 {
 		// in the root board, if there is a single possible move,
 		// we can skip calculations and immediately return the move.
 		// score is of course bogus in this case.
 		ab.singleChildren = true
-		return relativeMaterial(children, b, addChildren), ab.children.pool[firstChild].lastMove, ""
+		return evaluate(moves, &b, addChildren), variation{rootMoves[0]}, ""
+	}
+
+	var hashMove move
+	if ab.tt != nil {
+		// search best move from previous iteration first
+		if e, found := ab.tt.probe(b.zobrist); found {
+			hashMove = e.best
+		}
 	}
+	ab.order.sortMoves(&b, rootMoves, hashMove, 0)
 
 	var bestMove move
-	var alpha float32 = alphabetaMin
-	var beta float32 = alphabetaMax
+	alphaOrig := alpha
 
-	// handle first child
+	ab.pv.clear(0)
+
+	ab.pushPath(&b)
+	defer ab.popPath()
+
+	// handle first move
 	{
-		child := children.pool[firstChild]
-		score := alphaBeta(ab, child, -beta, -alpha, depth-1, addChildren)
-		score = -score
+		m := rootMoves[0]
+		ab.reportCurrMove(m, 1)
+		u := b.makeMove(m)
+		score := searchChild(ab, &b, alpha, beta, depth-1, 1, true, 0, addChildren)
+		b.unmakeMove(m, u)
+		if ab.cancelled {
+			return 0, nil, "" // score of cancelled search is meaningless
+		}
 		if ab.showSearch {
-			fmt.Printf("rootAlphaBeta: depth=%d nodes=%d score=%v move: %s\n", depth, ab.nodes, score, child.lastMove)
+			fmt.Printf("rootAlphaBeta: depth=%d nodes=%d score=%v move: %s\n", depth, ab.nodes, score, m)
 		}
+		ab.pv.update(0, m)
 		if score >= beta {
-			return beta, child.lastMove, ""
+			return beta, ab.pv.variation(), ""
 		}
 
-		// pick first child
+		// pick first move
 		alpha = score
-		bestMove = child.lastMove
+		bestMove = m
 	}
 
-	// scan remaining children
-	for _, child := range children.pool[firstChild+1:] {
-		if !ab.deadline.IsZero() {
-			// there is a timer
-			if ab.deadline.Before(time.Now()) {
-				// timer has expired
-				ab.cancelled = true
-				return 0, nullMove, ""
-			}
+	// scan remaining moves
+	for i, m := range rootMoves[1:] {
+		if ab.timeout() {
+			// timer has expired, node limit was reached or search was stopped
+			ab.cancelled = true
+			return 0, nil, ""
+		}
+		ab.reportCurrMove(m, i+2)
+		u := b.makeMove(m)
+		score := searchChild(ab, &b, alpha, beta, depth-1, 1, false, 0, addChildren)
+		b.unmakeMove(m, u)
+		if ab.cancelled {
+			return 0, nil, ""
 		}
-		score := alphaBeta(ab, child, -beta, -alpha, depth-1, addChildren)
-		score = -score
 		if ab.showSearch {
-			fmt.Printf("rootAlphaBeta: depth=%d nodes=%d score=%v move: %s\n", depth, ab.nodes, score, child.lastMove)
+			fmt.Printf("rootAlphaBeta: depth=%d nodes=%d score=%v move: %s\n", depth, ab.nodes, score, m)
 		}
 		if score >= beta {
-			return beta, child.lastMove, ""
+			ab.pv.update(0, m)
+			return beta, ab.pv.variation(), ""
 		}
 		if score > alpha {
 			alpha = score
-			bestMove = child.lastMove
+			bestMove = m
+			ab.pv.update(0, m)
 		}
 	}
 
-	return alpha, bestMove, ""
+	switch {
+	case len(ab.searchMoves) > 0 || len(ab.excludeMoves) > 0:
+		// root restricted to some moves: result does not hold for the position
+	case alpha > alphaOrig:
+		ab.ttStore(b.zobrist, depth, 0, ttExact, alpha, bestMove)
+	default:
+		ab.ttStore(b.zobrist, depth, 0, ttUpper, alpha, nullMove) // fail low
+	}
+
+	return alpha, ab.pv.variation(), ""
 }
 
-func alphaBeta(ab *alphaBetaState, b board, alpha, beta float32, depth int, addChildren bool) float32 {
+// searchChild implements principal variation search.
+//
+// https://www.chessprogramming.org/Principal_Variation_Search
+//
+// the first child is expected to be best, thus it is searched with the full window.
+// other children are only scouted with a null window to prove they are not better.
+// if a scout fails high, the child is searched again with the full window.
+// a reduced scout (late move reductions) that fails high is first
+// verified at full depth.
+func searchChild(ab *alphaBetaState, child *board, alpha, beta int, depth, ply int, first bool, reduction int, addChildren bool) int {
+	if first {
+		return -alphaBeta(ab, child, -beta, -alpha, depth, ply, addChildren)
+	}
+	if reduction > 0 {
+		ab.pruneStats.reductions++
+		score := -alphaBeta(ab, child, -alpha-pvsWindow, -alpha, depth-reduction, ply, addChildren)
+		if score <= alpha || ab.cancelled {
+			return score
+		}
+	}
+	score := -alphaBeta(ab, child, -alpha-pvsWindow, -alpha, depth, ply, addChildren)
+	if score > alpha && score < beta && !ab.cancelled {
+		ab.researches++
+		score = -alphaBeta(ab, child, -beta, -alpha, depth, ply, addChildren)
+	}
+	return score
+}
+
+func alphaBeta(ab *alphaBetaState, b *board, alpha, beta int, depth, ply int, addChildren bool) int {
 
-	children := ab.children
+	moves := ab.moves
+
+	ab.pv.clear(ply)
+
+	if ab.isDraw(b) {
+		return 0 // draw by repetition or fifty-move rule
+	}
+
+	// mate distance pruning: a shorter mate was already found elsewhere
+	alpha = max(alpha, matedIn(ply))
+	beta = min(beta, mateIn(ply+1))
+	if alpha >= beta {
+		return alpha
+	}
 
 	if depth < 1 {
-		return relativeMaterial(children, b, addChildren)
+		return quiescence(ab, b, alpha, beta, ply, addChildren)
 	}
 
-	countChildren := b.generateChildren(children)
-	if countChildren == 0 {
-		if b.kingInCheck() {
-			return alphabetaMin // checkmated
+	pvNode := beta-alpha > 2*pvsWindow
+
+	var hashMove move
+	if ab.tt != nil {
+		if e, found := ab.tt.probe(b.zobrist); found {
+			// a cutoff at a PV node would leave the reported line short
+			if score, cutoff := ttCutoff(e, alpha, beta, depth, ply); cutoff && !pvNode {
+				ab.ttHits++
+				return score
+			}
+			hashMove = e.best
+		}
+	}
+
+	inCheck := b.kingInCheck()
+	selective := !pvNode && !inCheck && !isMateScore(alpha) && !isMateScore(beta)
+
+	var staticEval int
+	if selective && (ab.prune.futility || ab.prune.nullMove) {
+		staticEval = evaluate(moves, b, addChildren)
+	}
+
+	// reverse futility: too far above beta to fall back below it
+	if selective && ab.prune.futility && depth <= futilityMaxDepth &&
+		staticEval-reverseFutilityMargin*depth >= beta {
+		ab.pruneStats.reverseFutility++
+		return beta
+	}
+
+	// null move: if passing the turn still fails high, a real move would too
+	if selective && ab.prune.nullMove && depth >= nullMoveMinDepth &&
+		!b.lastMove.isNull() && staticEval >= beta && b.hasNonPawnMaterial(b.turn) {
+		ab.pushPath(b)
+		null := b.makeNullMove()
+		score := -alphaBeta(ab, b, -beta, -beta+pvsWindow, depth-1-nullMoveReduction(depth), ply+1, addChildren)
+		b.unmakeNullMove(null)
+		ab.popPath()
+		if ab.cancelled {
+			return 0
+		}
+		if score >= beta {
+			ab.pruneStats.nullCutoffs++
+			return beta
+		}
+	}
+
+	// futility: quiet moves cannot raise static eval up to alpha
+	futile := selective && ab.prune.futility && depth <= futilityMaxDepth &&
+		staticEval+futilityMargin[depth] <= alpha
+
+	countMoves := b.generateMoves(moves)
+	if countMoves == 0 {
+		if inCheck {
+			return matedIn(ply) // checkmated
 		}
 		return 0 // draw
 	}
 
-	ab.nodes += int64(countChildren)
+	ab.nodes += int64(countMoves)
+
+	lastMoves := moves.last(countMoves)
+
+	ab.order.sortMoves(b, lastMoves, hashMove, ply)
 
-	firstChild := len(children.pool) - countChildren
-	lastChildren := children.pool[firstChild:]
+	alphaOrig := alpha
+	var bestMove move
+
+	ab.pushPath(b)
 
-	for _, child := range lastChildren {
-		if !ab.deadline.IsZero() {
-			// there is a timer
-			if ab.deadline.Before(time.Now()) {
-				// timer has expired
-				ab.cancelled = true
-				children.drop(countChildren)
-				return 0
+	for i, m := range lastMoves {
+		if ab.timeout() {
+			// timer has expired, node limit was reached or search was stopped
+			ab.cancelled = true
+			moves.drop(countMoves)
+			ab.popPath()
+			return 0
+		}
+		mayPrune := i > 0 && !inCheck && (futile || ab.prune.lmr) && ab.lateQuiet(b, m, ply)
+		u := b.makeMove(m)
+		var reduction int
+		if mayPrune && !b.kingInCheck() { // quiet move not giving check
+			if futile {
+				b.unmakeMove(m, u)
+				ab.pruneStats.futility++
+				continue
 			}
+			if !pvNode && depth >= lmrMinDepth && i >= lmrMinMoves {
+				reduction = lmrReduction(depth, i)
+			}
+		}
+		ext := ab.extension(b, countMoves)
+		ab.lineExtensions += ext
+		score := searchChild(ab, b, alpha, beta, depth-1+ext, ply+1, i == 0, reduction, addChildren)
+		ab.lineExtensions -= ext
+		b.unmakeMove(m, u)
+		if ab.cancelled {
+			// score of cancelled search is meaningless: keep it away from ordering
+			moves.drop(countMoves)
+			ab.popPath()
+			return 0
 		}
-		score := alphaBeta(ab, child, -beta, -alpha, depth-1, addChildren)
-		score = -score
 		if score >= beta {
-			children.drop(countChildren)
+			moves.drop(countMoves)
+			ab.popPath()
+			ab.stats.add(i == 0)
+			ab.order.cutoff(b, m, depth, ply)
+			ab.ttStore(b.zobrist, depth, ply, ttLower, beta, m)
 			return beta
 		}
 		if score > alpha {
 			alpha = score
+			bestMove = m
+			ab.pv.update(ply, m)
 		}
 	}
 
-	children.drop(countChildren)
+	moves.drop(countMoves)
+	ab.popPath()
+
+	if alpha > alphaOrig {
+		ab.ttStore(b.zobrist, depth, ply, ttExact, alpha, bestMove)
+	} else {
+		ab.ttStore(b.zobrist, depth, ply, ttUpper, alpha, nullMove)
+	}
+
 	return alpha
 }
+
+// timeout reports whether search must be interrupted.
+func (ab *alphaBetaState) timeout() bool {
+	if ab.nodeLimit > 0 && ab.nodes >= ab.nodeLimit {
+		return true
+	}
+	return ab.control.expired()
+}
+
+// lateQuiet reports whether move m, about to be played on b, may be pruned
+// or reduced: not a capture or promotion, not a killer.
+// moves giving check must also be spared, which is only known after the move.
+func (ab *alphaBetaState) lateQuiet(b *board, m move, ply int) bool {
+	return !b.isNoisy(m) && !ab.order.isKiller(m, ply)
+}
+
+func (ab *alphaBetaState) reportCurrMove(m move, number int) {
+	if ab.currMove != nil {
+		ab.currMove(m, number)
+	}
+}
+
+func (ab *alphaBetaState) ttStore(key uint64, depth, ply int, bound ttBound, score int, best move) {
+	if ab.tt == nil || ab.cancelled {
+		return // do not record scores from interrupted search
+	}
+	ab.tt.store(key, depth, bound, scoreToTT(score, ply), best)
+}
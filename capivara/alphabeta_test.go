@@ -0,0 +1,73 @@
+package main
+
+import "testing"
+
+// TestAspiration: aspiration windows must find the same score as full window,
+// even when the previous score is far off.
+func TestAspiration(t *testing.T) {
+	for _, brd := range []string{builtinBoard, b2, b7, b9} {
+		game := newGame()
+		game.loadFromString(brd)
+		b := game.history[len(game.history)-1]
+
+		depth := 4
+
+		moves := defaultMovePool
+		moves.reset()
+		full := alphaBetaState{moves: moves}
+		expected, _, _ := rootAlphaBeta(&full, b, depth, false)
+
+		for _, previous := range []int{expected, expected - 300, expected + 300} {
+			moves.reset()
+			ab := alphaBetaState{moves: moves}
+			score, pv, _ := aspirationSearch(&ab, b, depth, previous, false)
+			if score != expected {
+				t.Errorf("previous=%v: score=%v expected=%v pv=[%s] fails=%d", previous, score, expected, pv, ab.aspirationFails)
+			}
+		}
+	}
+}
+
+func TestRootWindowFail(t *testing.T) {
+	game := newGame()
+	game.loadFromString(b2)
+	b := game.history[len(game.history)-1]
+
+	depth := 3
+
+	moves := defaultMovePool
+	moves.reset()
+	full := alphaBetaState{moves: moves}
+	expected, _, _ := rootAlphaBeta(&full, b, depth, false)
+
+	moves.reset()
+	low := alphaBetaState{moves: moves}
+	alpha := expected + 1
+	if score, _, _ := rootAlphaBetaWindow(&low, b, depth, alpha, alpha+1, false); score > alpha {
+		t.Errorf("window above score must fail low: score=%v alpha=%v", score, alpha)
+	}
+
+	moves.reset()
+	high := alphaBetaState{moves: moves}
+	beta := expected - 1
+	if score, _, _ := rootAlphaBetaWindow(&high, b, depth, beta-1, beta, false); score < beta {
+		t.Errorf("window below score must fail high: score=%v beta=%v", score, beta)
+	}
+}
+
+// TestMateDistance: deeper search must still prefer the shortest mate.
+func TestMateDistance(t *testing.T) {
+	game := newGame()
+	game.loadFromString(b11)
+	b := game.history[len(game.history)-1]
+
+	for depth := 2; depth <= 5; depth++ {
+		moves := defaultMovePool
+		moves.reset()
+		ab := alphaBetaState{moves: moves}
+		score, pv, _ := rootAlphaBeta(&ab, b, depth, false)
+		if score != mateIn(1) {
+			t.Errorf("depth=%d score=%d expected=%d pv=[%s]", depth, score, mateIn(1), pv)
+		}
+	}
+}
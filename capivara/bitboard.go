@@ -0,0 +1,270 @@
+package main
+
+import (
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+// bitboards
+//
+// https://www.chessprogramming.org/Bitboards
+//
+// board keeps, besides the square array, one bitboard per piece kind and
+// one per color. bit n stands for location n: a1=0, b1=1, ..., h8=63.
+//
+// attacks by leapers (pawn, knight, king) come from tables indexed by location.
+// attacks by sliders (bishop, rook, queen) come from magic bitboards:
+//
+// https://www.chessprogramming.org/Magic_Bitboards
+//
+// the blockers on the relevant rays of a square are multiplied by a magic
+// number and shifted, giving a perfect hash into that square's attack table.
+// no PEXT instruction is needed. magic numbers were found by findMagic
+// and are kept in magics.go, since searching them takes a noticeable
+// fraction of a second at startup.
+
+type bitboard uint64
+
+func bit(loc location) bitboard {
+	return 1 << uint(loc)
+}
+
+// first returns the lowest location in the set.
+func (bb bitboard) first() location {
+	return location(bits.TrailingZeros64(uint64(bb)))
+}
+
+// pop removes and returns the lowest location in the set.
+func (bb *bitboard) pop() location {
+	loc := bb.first()
+	*bb &= *bb - 1
+	return loc
+}
+
+func (bb bitboard) count() int {
+	return bits.OnesCount64(uint64(bb))
+}
+
+func (bb bitboard) String() string {
+	var sb strings.Builder
+	for row := 7; row >= 0; row-- {
+		for col := 0; col < 8; col++ {
+			if bb&bit(location(row*8+col)) != 0 {
+				sb.WriteByte('x')
+			} else {
+				sb.WriteByte('.')
+			}
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// magicSeed is the seed findMagic used to find the numbers in magics.go
+const magicSeed = 0x6d61676963 // "magic"
+
+type magicEntry struct {
+	mask    bitboard // relevant blockers, board edges excluded
+	magic   uint64
+	shift   uint
+	attacks []bitboard
+}
+
+func (m *magicEntry) index(occupied bitboard) uint64 {
+	return (uint64(occupied&m.mask) * m.magic) >> m.shift
+}
+
+type attackTables struct {
+	pawn   [2][64]bitboard // color => location => squares attacked by pawn
+	knight [64]bitboard
+	king   [64]bitboard
+	rook   [64]magicEntry
+	bishop [64]magicEntry
+
+	between [64][64]bitboard // squares strictly between two aligned locations
+	line    [64][64]bitboard // whole line through two aligned locations
+}
+
+var (
+	rookDirections   = [4][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}}
+	bishopDirections = [4][2]int{{1, 1}, {1, -1}, {-1, 1}, {-1, -1}}
+	knightJumps      = [8][2]int{{-2, -1}, {-2, 1}, {2, -1}, {2, 1}, {-1, -2}, {1, -2}, {-1, 2}, {1, 2}}
+	kingSteps        = [8][2]int{{-1, -1}, {-1, 0}, {-1, 1}, {0, -1}, {0, 1}, {1, -1}, {1, 0}, {1, 1}}
+)
+
+var attack = newAttackTables()
+
+func newAttackTables() *attackTables {
+	t := &attackTables{}
+	r := splitMix64{state: magicSeed}
+	for loc := location(0); loc < 64; loc++ {
+		row, col := int(loc/8), int(loc%8)
+		t.knight[loc] = leaperAttacks(row, col, knightJumps[:])
+		t.king[loc] = leaperAttacks(row, col, kingSteps[:])
+		t.pawn[colorWhite][loc] = leaperAttacks(row, col, [][2]int{{1, -1}, {1, 1}})
+		t.pawn[colorBlack][loc] = leaperAttacks(row, col, [][2]int{{-1, -1}, {-1, 1}})
+
+		var ok bool
+		if t.rook[loc], ok = newMagicEntry(loc, rookDirections[:], rookMagics[loc]); !ok {
+			t.rook[loc] = findMagic(loc, rookDirections[:], &r) // only if magics.go were broken
+		}
+		if t.bishop[loc], ok = newMagicEntry(loc, bishopDirections[:], bishopMagics[loc]); !ok {
+			t.bishop[loc] = findMagic(loc, bishopDirections[:], &r)
+		}
+
+		t.fillLines(loc, rookDirections[:])
+		t.fillLines(loc, bishopDirections[:])
+	}
+	return t
+}
+
+// fillLines walks each ray from loc, filling between and line
+// for every square on it. locations not aligned are left empty.
+func (t *attackTables) fillLines(loc location, directions [][2]int) {
+	row, col := int(loc/8), int(loc%8)
+	for _, d := range directions {
+		full := slowSlidingAttacks(loc, 0, [][2]int{d, {-d[0], -d[1]}}) | bit(loc)
+		var ray bitboard
+		for r, c := row+d[0], col+d[1]; onBoard(r, c); r, c = r+d[0], c+d[1] {
+			dst := location(r*8 + c)
+			t.between[loc][dst] = ray
+			t.line[loc][dst] = full
+			ray |= bit(dst)
+		}
+	}
+}
+
+func leaperAttacks(row, col int, steps [][2]int) bitboard {
+	var bb bitboard
+	for _, s := range steps {
+		if r, c := row+s[0], col+s[1]; onBoard(r, c) {
+			bb |= bit(location(r*8 + c))
+		}
+	}
+	return bb
+}
+
+// slowSlidingAttacks walks each ray until the first blocker, inclusive.
+// it is used only to fill the attack tables.
+func slowSlidingAttacks(loc location, occupied bitboard, directions [][2]int) bitboard {
+	var bb bitboard
+	row, col := int(loc/8), int(loc%8)
+	for _, d := range directions {
+		for r, c := row+d[0], col+d[1]; onBoard(r, c); r, c = r+d[0], c+d[1] {
+			b := bit(location(r*8 + c))
+			bb |= b
+			if occupied&b != 0 {
+				break
+			}
+		}
+	}
+	return bb
+}
+
+// relevantMask lists the squares whose occupation may block a ray from loc.
+// the last square of each ray never blocks anything behind it.
+func relevantMask(loc location, directions [][2]int) bitboard {
+	var bb bitboard
+	row, col := int(loc/8), int(loc%8)
+	for _, d := range directions {
+		for r, c := row+d[0], col+d[1]; onBoard(r+d[0], c+d[1]); r, c = r+d[0], c+d[1] {
+			bb |= bit(location(r*8 + c))
+		}
+	}
+	return bb
+}
+
+// magicSubsets enumerates every blocker subset of the relevant mask of loc
+// (carry-rippler), with the attacks each subset leaves.
+func magicSubsets(loc location, directions [][2]int) (bitboard, []bitboard, []bitboard) {
+	mask := relevantMask(loc, directions)
+	size := 1 << mask.count()
+	occupancy := make([]bitboard, 0, size)
+	reference := make([]bitboard, 0, size)
+	for sub := bitboard(0); ; {
+		occupancy = append(occupancy, sub)
+		reference = append(reference, slowSlidingAttacks(loc, sub, directions))
+		sub = (sub - mask) & mask
+		if sub == 0 {
+			break
+		}
+	}
+	return mask, occupancy, reference
+}
+
+// newMagicEntry fills the attack table for a known magic number.
+// it fails when the magic does not hash the subsets without destructive collision.
+func newMagicEntry(loc location, directions [][2]int, magic uint64) (magicEntry, bool) {
+	mask, occupancy, reference := magicSubsets(loc, directions)
+	m := magicEntry{mask: mask, magic: magic, shift: uint(64 - mask.count()), attacks: make([]bitboard, len(occupancy))}
+	used := make([]bool, len(occupancy))
+	for i, occ := range occupancy {
+		idx := m.index(occ)
+		if used[idx] && m.attacks[idx] != reference[i] {
+			return m, false
+		}
+		used[idx] = true
+		m.attacks[idx] = reference[i]
+	}
+	return m, true
+}
+
+// findMagic searches a magic number for loc by trial and error.
+func findMagic(loc location, directions [][2]int, r *splitMix64) magicEntry {
+	mask, occupancy, reference := magicSubsets(loc, directions)
+	m := magicEntry{mask: mask, shift: uint(64 - mask.count()), attacks: make([]bitboard, len(occupancy))}
+	used := make([]int, len(occupancy)) // attempt that last filled each slot, avoids clearing
+
+SEARCH:
+	for attempt := 1; ; attempt++ {
+		m.magic = r.next() & r.next() & r.next() // sparse candidates hash better
+		if bits.OnesCount64((uint64(mask)*m.magic)>>56) < 6 {
+			continue
+		}
+		for i, occ := range occupancy {
+			idx := m.index(occ)
+			if used[idx] == attempt && m.attacks[idx] != reference[i] {
+				continue SEARCH // destructive collision
+			}
+			used[idx] = attempt
+			m.attacks[idx] = reference[i]
+		}
+		return m
+	}
+}
+
+func rookAttacks(loc location, occupied bitboard) bitboard {
+	m := &attack.rook[loc]
+	return m.attacks[m.index(occupied)]
+}
+
+func bishopAttacks(loc location, occupied bitboard) bitboard {
+	m := &attack.bishop[loc]
+	return m.attacks[m.index(occupied)]
+}
+
+func onBoard(row, col int) bool {
+	return row >= 0 && row < 8 && col >= 0 && col < 8
+}
+
+func (b *board) occupied() bitboard {
+	return b.bbColor[colorWhite] | b.bbColor[colorBlack]
+}
+
+// checkBitboards verifies bitboards agree with the square array.
+func (b *board) checkBitboards() error {
+	var kinds [7]bitboard
+	var colors [2]bitboard
+	for loc, p := range b.square {
+		if p == pieceNone {
+			continue
+		}
+		kinds[p.kind()] |= bit(location(loc))
+		colors[p.color()] |= bit(location(loc))
+	}
+	if kinds != b.bbKind || colors != b.bbColor {
+		return fmt.Errorf("bitboards out of sync with squares")
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMagicNumbers(t *testing.T) {
+	r := splitMix64{state: magicSeed}
+	for loc := location(0); loc < 64; loc++ {
+		if m := findMagic(loc, rookDirections[:], &r); m.magic != rookMagics[loc] {
+			t.Errorf("rook %s: found magic 0x%016x table has 0x%016x", locToStr(loc), m.magic, rookMagics[loc])
+		}
+		if m := findMagic(loc, bishopDirections[:], &r); m.magic != bishopMagics[loc] {
+			t.Errorf("bishop %s: found magic 0x%016x table has 0x%016x", locToStr(loc), m.magic, bishopMagics[loc])
+		}
+	}
+}
+
+func TestSlidingAttacks(t *testing.T) {
+	r := splitMix64{state: 1}
+	for loc := location(0); loc < 64; loc++ {
+		for i := 0; i < 100; i++ {
+			occupied := bitboard(r.next() & r.next()) // about one square in four
+			if got, expected := rookAttacks(loc, occupied), slowSlidingAttacks(loc, occupied, rookDirections[:]); got != expected {
+				t.Fatalf("rook %s occupied=%016x:\n%vexpected:\n%v", locToStr(loc), uint64(occupied), got, expected)
+			}
+			if got, expected := bishopAttacks(loc, occupied), slowSlidingAttacks(loc, occupied, bishopDirections[:]); got != expected {
+				t.Fatalf("bishop %s occupied=%016x:\n%vexpected:\n%v", locToStr(loc), uint64(occupied), got, expected)
+			}
+		}
+	}
+}
+
+// TestBitboardsInSync walks the tree below a position rich in castling,
+// en passant and promotion, checking bitboards against squares.
+func TestBitboardsInSync(t *testing.T) {
+	b, err := fenParse(strings.Fields("r3k2r/pPppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPpP/R3K2R w KQkq - 0 1"))
+	if err != nil {
+		t.Fatalf("fen: %v", err)
+	}
+
+	children := newPool()
+	var walk func(b board, depth int)
+	walk = func(b board, depth int) {
+		if errSync := b.checkBitboards(); errSync != nil {
+			t.Fatalf("after %s: %v", b.lastMove, errSync)
+		}
+		if depth == 0 {
+			return
+		}
+		count := b.generateChildren(children)
+		for _, c := range children.pool[len(children.pool)-count:] {
+			walk(c, depth-1)
+		}
+		children.drop(count)
+	}
+	walk(b, 3)
+}
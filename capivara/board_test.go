@@ -9,13 +9,14 @@ func BenchmarkCastling(b *testing.B) {
 	game.loadFromString(castling)
 	brd := game.history[len(game.history)-1]
 
-	children := defaultBoardPool
-	ab := alphaBetaState{children: children}
+	moves := defaultMovePool
+	ab := alphaBetaState{moves: moves}
 
 	var mv move
 	for n := 0; n < b.N; n++ {
-		children.reset()
-		_, m, _ := rootAlphaBeta(&ab, brd, 2, false)
+		moves.reset()
+		_, pv, _ := rootAlphaBeta(&ab, brd, 2, false)
+		m := pv.best()
 		mv = m // record call result to prevent compiler from eliminating function call
 	}
 	testMove = mv // record bench result to prevent the compiler from eliminating the test
@@ -26,13 +27,14 @@ func BenchmarkCastlingAddChildren(b *testing.B) {
 	game.loadFromString(castling)
 	brd := game.history[len(game.history)-1]
 
-	children := defaultBoardPool
-	ab := alphaBetaState{children: children}
+	moves := defaultMovePool
+	ab := alphaBetaState{moves: moves}
 
 	var mv move
 	for n := 0; n < b.N; n++ {
-		children.reset()
-		_, m, _ := rootAlphaBeta(&ab, brd, 2, true)
+		moves.reset()
+		_, pv, _ := rootAlphaBeta(&ab, brd, 2, true)
+		m := pv.best()
 		mv = m // record call result to prevent compiler from eliminating function call
 	}
 	testMove = mv // record bench result to prevent the compiler from eliminating the test
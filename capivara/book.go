@@ -9,8 +9,20 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 )
 
+var bookRand = rand.New(rand.NewSource(time.Now().UnixNano()))
+
+// seedBook re-seeds book randomness.
+// seed 0 means seed from clock, otherwise book picks are reproducible.
+func seedBook(seed int64) {
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	bookRand = rand.New(rand.NewSource(seed))
+}
+
 func (game *gameState) bookLookup() string {
 
 	position := game.position()
@@ -57,7 +69,7 @@ func (game *gameState) bookPick(position string, moves []bookMove) string {
 		sum += w
 	}
 
-	r := int(rand.Int31n(int32(sum))) // 0..sum-1
+	r := int(bookRand.Int31n(int32(sum))) // 0..sum-1
 
 	// 2 3 4
 	// 2 5 9
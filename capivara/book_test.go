@@ -42,3 +42,28 @@ func TestPosition(t *testing.T) {
 		}
 	}
 }
+
+func TestBookSeed(t *testing.T) {
+	game := newGame()
+	game.loadFromString(builtinBoard)
+	game.bookSeed = 42
+
+	picks := func() []string {
+		game.startNewGame()
+		var moves []string
+		for i := 0; i < 10; i++ {
+			moves = append(moves, game.bookLookup())
+		}
+		return moves
+	}
+
+	first := picks()
+	second := picks()
+
+	for i := range first {
+		if first[i] != second[i] {
+			t.Errorf("book picks differ with fixed seed: %v %v", first, second)
+			break
+		}
+	}
+}
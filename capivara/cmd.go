@@ -26,13 +26,17 @@ var tableCmd = []command{
 	{"help", cmdHelp, "show help"},
 	{"load", cmdLoad, "load file - load board from file"},
 	{"move", cmdMove, "change piece position"},
+	{"mate", cmdMate, "mate N [duration] - solve mate in N moves"},
 	{"negamax", cmdNegamax, "negamax [depth] - negamax search"},
+	{"newgame", cmdNewGame, "reset board and clear per-game search state"},
 	{"play", cmdPlay, "play move"},
 	{"perft", cmdPerft, "perft depth - count moves to depth"},
 	{"pst", cmdPst, "show pst"},
 	{"reset", cmdReset, "reset board to initial position"},
-	{"search", cmdSearch, "search [ms] - search"},
+	{"search", cmdSearch, "search [duration] [multipv N] - search"},
+	{"see", cmdSee, "see [move] - static exchange evaluation of move or of all captures"},
 	{"switch", cmdSwitch, "switch turn"},
+	{"tt", cmdTT, "tt [MB] - show or resize transposition table"},
 	{"undo", cmdUndo, "undo last played move"},
 	{"uci", cmdUci, "start UCI mode"},
 	{"version", cmdVersion, "show version"},
@@ -45,8 +49,7 @@ func cmdClear(_ []command, game *gameState, _ []string) {
 func cmdCastling(_ []command, game *gameState, _ []string) {
 	last := len(game.history) - 1
 	b := &game.history[last]
-	b.flags[colorWhite] |= lostCastlingLeft | lostCastlingRight // disable castling for white
-	b.flags[colorBlack] |= lostCastlingLeft | lostCastlingRight // disable castling for black
+	b.disableCastling()
 	fmt.Println("castling disabled")
 }
 
@@ -60,7 +63,9 @@ func cmdFen(_ []command, game *gameState, tokens []string) {
 		fmt.Printf("usage: fen FEN-string\n")
 		return
 	}
-	game.loadFromFen(tokens[1:])
+	if errFen := game.loadFromFen(tokens[1:]); errFen != nil {
+		fmt.Println(errFen)
+	}
 }
 
 func cmdHelp(cmds []command, _ *gameState, _ []string) {
@@ -187,17 +192,17 @@ func cmdAlphaBeta(_ []command, game *gameState, tokens []string) {
 	last := len(game.history) - 1
 	b := game.history[last]
 
-	children := defaultBoardPool
-	children.reset()
-	ab := alphaBetaState{showSearch: true, children: children}
+	moves := defaultMovePool
+	moves.reset()
+	ab := alphaBetaState{showSearch: true, moves: moves, tt: game.tt, path: game.historyPath(), order: game.order, prune: game.prune}
 
 	begin := time.Now()
 
-	score, move, comment := rootAlphaBeta(&ab, b, depth, game.addChildren)
+	score, pv, comment := rootAlphaBeta(&ab, b, depth, game.addChildren)
 
 	speed := getSpeed(ab.nodes, begin)
 
-	fmt.Printf("alphabeta: nodes=%d speed=%v knodes/s best score=%v move=%s (%s)\n", ab.nodes, speed, score, move, comment)
+	fmt.Printf("alphabeta: nodes=%d speed=%v knodes/s tthits=%d %v researches=%d %v best score=%v move=%s pv=%s (%s)\n", ab.nodes, speed, ab.ttHits, ab.stats, ab.researches, ab.pruneStats, score, pv.best(), pv, comment)
 }
 
 func getSpeed(nodes int64, begin time.Time) int {
@@ -221,6 +226,37 @@ func cmdPlay(_ []command, game *gameState, tokens []string) {
 			return
 		}
 	}
+
+	if reason := game.drawReason(); reason != "" {
+		fmt.Printf("draw: %s\n", reason)
+	}
+}
+
+func cmdSee(_ []command, game *gameState, tokens []string) {
+	b := game.history[len(game.history)-1]
+
+	if len(tokens) > 1 {
+		m, errMove := newMove(tokens[1])
+		if errMove != nil {
+			fmt.Printf("bad move: %s: %v\n", tokens[1], errMove)
+			return
+		}
+		if b.square[m.src] == pieceNone || b.square[m.src].color() != b.turn {
+			fmt.Printf("no %s piece at: %s\n", b.turn.name(), locToStr(m.src))
+			return
+		}
+		fmt.Printf("see %s: %d\n", m, b.see(m))
+		return
+	}
+
+	moves := defaultMovePool
+	moves.reset()
+	countMoves := b.generateMoves(moves)
+	for _, m := range moves.last(countMoves) {
+		if b.isNoisy(m) {
+			fmt.Printf("see %s: %d\n", m, b.see(m))
+		}
+	}
 }
 
 func cmdPerft(_ []command, game *gameState, tokens []string) {
@@ -239,25 +275,16 @@ func cmdPerft(_ []command, game *gameState, tokens []string) {
 
 	last := len(game.history) - 1
 	b := game.history[last]
-	//buf := make([]board, 0, 100)
-	//buf := []board(nil)
-	children := defaultBoardPool
-	children.reset()
-	countChildren := b.generateChildren(children)
 
-	fmt.Printf("perft depth=%d\n", d)
+	fmt.Printf("perft depth=%d move generator: %v\n", d, game.moveGen)
 
-	total := int64(countChildren)
-	var nodes int64
-	for _, c := range children.pool {
-		begin := time.Now()
-		n, t := perft(c, d, children)
+	begin := time.Now()
+	nodes, total := game.moveGen.perft(b, d+1, func(m move, n, t int64) {
 		elap := time.Since(begin)
 		speed := getSpeedElapsed(t, elap)
-		fmt.Printf("%s nodes=%d total_nodes=%d elapsed=%v speed=%v knodes/s\n", c.lastMove, n, t, elap, speed)
-		nodes += n
-		total += t
-	}
+		fmt.Printf("%s nodes=%d total_nodes=%d elapsed=%v speed=%v knodes/s\n", m, n, t, elap, speed)
+		begin = time.Now()
+	})
 
 	perftElap := time.Since(perftBegin)
 	perftSpeed := getSpeedElapsed(total, perftElap)
@@ -306,26 +333,58 @@ func cmdReset(_ []command, game *gameState, _ []string) {
 	game.loadFromString(builtinBoard)
 }
 
+func cmdNewGame(_ []command, game *gameState, _ []string) {
+	game.startNewGame()
+}
+
+// startNewGame clears every piece of per-game state.
+func (game *gameState) startNewGame() {
+	game.loadFromString(builtinBoard) // reset history
+	if game.tt != nil {
+		game.tt.clear()
+	}
+	game.order.clear()
+	if game.bookSeed != 0 {
+		seedBook(game.bookSeed)
+	}
+}
+
 func cmdSearch(_ []command, game *gameState, tokens []string) {
 	availTime := 5 * time.Second
+	multiPV := 1
 
-	if len(tokens) > 1 {
-		a, errParse := time.ParseDuration(tokens[1])
+	for i := 1; i < len(tokens); i++ {
+		if tokens[i] == "multipv" {
+			if i+1 >= len(tokens) {
+				fmt.Printf("search: missing multipv value\n")
+				return
+			}
+			n, errConv := strconv.Atoi(tokens[i+1])
+			if errConv != nil || n < 1 || n > maxMultiPV {
+				fmt.Printf("search: bad multipv: '%s'\n", tokens[i+1])
+				return
+			}
+			multiPV = n
+			i++
+			continue
+		}
+		a, errParse := time.ParseDuration(tokens[i])
 		if errParse != nil {
-			fmt.Printf("search: bad duration: '%s': %v\n", tokens[1], errParse)
+			fmt.Printf("search: bad duration: '%s': %v\n", tokens[i], errParse)
 			return
 		}
 		availTime = a
 	}
 
-	game.searchPerMove(availTime, availTime)
-}
-
-func (game *gameState) search(availTime time.Duration) string {
-	return game.searchPerMove(availTime, availTime/20)
+	limits := searchLimits{moveTime: availTime, multiPV: multiPV}
+	tm := newTimeManager(limits, game.history[len(game.history)-1].turn)
+	game.searchPerMove(newSearchControl(tm.hard), limits, tm)
 }
 
-func (game *gameState) searchPerMove(availTime, perMove time.Duration) string {
+// searchPerMove runs iterative deepening until control expires
+// or limits for depth, nodes or mate are reached.
+// tm must be the time manager that armed control.
+func (game *gameState) searchPerMove(control *searchControl, limits searchLimits, tm *timeManager) string {
 
 	if game.dumbBook {
 		best := game.bookLookup()
@@ -338,11 +397,10 @@ func (game *gameState) searchPerMove(availTime, perMove time.Duration) string {
 	begin := time.Now()
 	var totalNodes int64
 
-	deadline := begin.Add(perMove)
-
 	var bestDepth int
-	var bestScore float32
+	var bestScore int
 	var bestMove move
+	var bestPV variation
 	var bestComment string
 
 	if game.cpuprofile != "" {
@@ -358,63 +416,201 @@ func (game *gameState) searchPerMove(availTime, perMove time.Duration) string {
 	last := len(game.history) - 1
 	b := game.history[last]
 
+	game.order.age()
+	var stats orderStats
+	var prunes pruneStats
+
+	moves := newMovePool() // helper threads have their own pools
+	helpers := game.startHelpers(control, b, limits)
+
+	multiPV := max(limits.multiPV, 1)
+	var mainLine int // multipv index of main line, zero when MultiPV is off
+	if multiPV > 1 {
+		mainLine = 1
+	}
+
 LOOP:
-	for depth := 1; ; depth++ {
-		game.print(fmt.Sprintf("search depth=%d avail=%v remain=%v\n", depth, availTime, time.Until(deadline)))
+	for depth := 1; depth <= limits.maxDepth(); depth++ {
+		if !game.uci {
+			game.print(fmt.Sprintf("search depth=%d remain=%v\n", depth, remaining(control)))
+		}
 		depthBegin := time.Now()
-		if deadline.Before(depthBegin) {
+		if control.expired() {
 			game.print(fmt.Sprintf("search depth=%d: timeout\n", depth))
 			break
 		}
+		var nodeLimit int64
+		if limits.nodes > 0 {
+			nodeLimit = limits.nodes - totalNodes // remaining node budget
+			if nodeLimit < 1 {
+				game.print(fmt.Sprintf("search depth=%d: node limit\n", depth))
+				break
+			}
+		}
+
+		newState := func(exclude []move) alphaBetaState {
+			moves.reset()
+			ab := alphaBetaState{showSearch: false, control: control, moves: moves, tt: game.tt, path: game.historyPath(), nodeLimit: nodeLimit, searchMoves: limits.searchMoves, excludeMoves: exclude, order: game.order, prune: game.prune}
+			if game.uci {
+				ab.currMove = uciCurrMove(begin, depth)
+			}
+			return ab
+		}
 
-		children := defaultBoardPool
-		children.reset()
-		ab := alphaBetaState{showSearch: false, deadline: deadline, children: children}
+		ab := newState(nil)
 
-		score, move, comment := rootAlphaBeta(&ab, b, depth, game.addChildren)
+		var score int
+		var pv variation
+		var comment string
+		if bestDepth >= aspirationMinDepth {
+			score, pv, comment = aspirationSearch(&ab, b, depth, bestScore, game.addChildren)
+		} else {
+			score, pv, comment = rootAlphaBeta(&ab, b, depth, game.addChildren)
+		}
+		m := pv.best()
 
 		totalNodes += ab.nodes
+		stats.cutoffs += ab.stats.cutoffs
+		stats.firstCutoffs += ab.stats.firstCutoffs
+		prunes.add(ab.pruneStats)
 
 		if ab.cancelled {
 			game.print(fmt.Sprintf("search depth=%d: timeout - cancelled\n", depth))
 			break
 		}
 
-		speed := getSpeed(ab.nodes, depthBegin)
-
-		game.print(fmt.Sprintf("search depth=%d: nodes=%d speed=%v knodes/s best score=%v move=%s (%s)\n", depth, ab.nodes, speed, score, move, comment))
+		if game.uci {
+			fmt.Println(uciInfo(depth, ab.seldepth, mainLine, score, totalNodes+helpers.nodes.Load(), time.Since(begin), pv))
+		} else {
+			speed := getSpeed(ab.nodes, depthBegin)
+			game.print(fmt.Sprintf("search depth=%d: nodes=%d speed=%v knodes/s tthits=%d %v researches=%d aspiration fails=%d %v best score=%v move=%s pv=%s (%s)\n", depth, ab.nodes, speed, ab.ttHits, ab.stats, ab.researches, ab.aspirationFails, ab.pruneStats, score, m, pv, comment))
+		}
 		bestDepth = depth
 		bestScore = score
-		bestMove = move
+		bestMove = m
+		bestPV = pv
 		bestComment = comment
+
+		// MultiPV: each further line excludes best moves from previous lines
+		var cancelled bool
+		excluded := []move{m}
+		for k := 2; k <= multiPV && !ab.singleChildren && comment == ""; k++ {
+			line := newState(excluded)
+			lineScore, linePV, _ := rootAlphaBeta(&line, b, depth, game.addChildren)
+			totalNodes += line.nodes
+			if line.cancelled {
+				cancelled = true
+				break
+			}
+			if linePV.best().isNull() {
+				break // fewer moves than lines
+			}
+			if game.uci {
+				fmt.Println(uciInfo(depth, line.seldepth, k, lineScore, totalNodes+helpers.nodes.Load(), time.Since(begin), linePV))
+			} else {
+				game.print(fmt.Sprintf("search depth=%d: multipv=%d score=%v move=%s pv=%s\n", depth, k, lineScore, linePV.best(), linePV))
+			}
+			excluded = append(excluded, linePV.best())
+		}
+		if cancelled {
+			game.print(fmt.Sprintf("search depth=%d: timeout - multipv cancelled\n", depth))
+			break
+		}
+
 		if ab.singleChildren {
-			game.print(fmt.Sprintf("search depth=%d: move=%s single move\n", depth, move))
+			game.print(fmt.Sprintf("search depth=%d: move=%s single move\n", depth, m))
 			break
 		}
 		switch comment {
 		case "checkmated", "checkmate", "draw":
 			break LOOP
 		}
-		if bestScore == alphabetaMax {
-			game.print(fmt.Sprintf("search depth=%d: nodes=%d best score=%v move: %s found checkmate\n", depth, ab.nodes, score, move))
+		if bestScore >= scoreMateBound {
+			game.print(fmt.Sprintf("search depth=%d: nodes=%d best score=%v move: %s found checkmate\n", depth, ab.nodes, score, m))
+			break
+		}
+		tm.update(depth, m)
+		if tm.stopEarly(control) {
+			game.print(fmt.Sprintf("search depth=%d: soft time limit %v (scale=%.2f)\n", depth, tm.soft, tm.scale))
 			break
 		}
+		if tm.checkForced(control, depth) {
+			verify := newState([]move{m})
+			verify.currMove = nil
+			forced := forcedMove(&verify, b, depth, score, game.addChildren)
+			totalNodes += verify.nodes
+			if forced {
+				game.print(fmt.Sprintf("search depth=%d: move=%s forced\n", depth, m))
+				break
+			}
+		}
 	}
 
+	helpers.stop()
+	totalNodes += helpers.nodes.Load()
+
 	speed := getSpeed(totalNodes, begin)
 
-	game.println(fmt.Sprintf("search: best depth=%d nodes=%d speed=%v knodes/s score=%v move=%s elapsed=%v", bestDepth, totalNodes, speed, bestScore, bestMove, time.Since(begin)))
+	game.println(fmt.Sprintf("search: threads=%d best depth=%d nodes=%d speed=%v knodes/s %v %v score=%v move=%s pv=%s elapsed=%v", game.threads, bestDepth, totalNodes, speed, stats, prunes, bestScore, bestMove, bestPV, time.Since(begin)))
 
 	if bestMove.isNull() {
+		if bestComment == "" {
+			// stopped before first iteration completed
+			return game.anyMove()
+		}
 		return bestComment
 	}
 
 	return bestMove.String()
 }
 
+func remaining(control *searchControl) string {
+	deadline := control.getDeadline()
+	if deadline.IsZero() {
+		return "infinite"
+	}
+	return time.Until(deadline).String()
+}
+
+// anyMove returns some valid move for the current position.
+func (game *gameState) anyMove() string {
+	last := len(game.history) - 1
+	b := game.history[last]
+	moves := defaultMovePool
+	moves.reset()
+	if b.generateMoves(moves) == 0 {
+		return ""
+	}
+	return moves.pool[0].String()
+}
+
 func cmdSwitch(_ []command, game *gameState, _ []string) {
 	b := &game.history[len(game.history)-1] // will update in place
-	b.turn = colorInverse(b.turn)           // switch color
+	b.switchTurn()                          // switch color
+}
+
+func cmdTT(_ []command, game *gameState, tokens []string) {
+	if len(tokens) > 1 {
+		mb, errConv := strconv.Atoi(tokens[1])
+		if errConv != nil {
+			fmt.Printf("tt: bad size: '%s': %v\n", tokens[1], errConv)
+			return
+		}
+		game.setHash(mb)
+	}
+	fmt.Printf("transposition table: %v\n", game.tt)
+}
+
+func (game *gameState) setHash(sizeMB int) {
+	game.tt = newTranspositionTable(sizeMB)
+}
+
+// hashMB is the memory granted by the Hash option.
+func (game *gameState) hashMB() int {
+	if game.tt == nil {
+		return defaultHashMB
+	}
+	return game.tt.sizeMB()
 }
 
 func cmdUci(_ []command, game *gameState, tokens []string) {
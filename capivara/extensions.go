@@ -0,0 +1,33 @@
+package main
+
+// search extensions
+//
+// https://www.chessprogramming.org/Check_Extensions
+// https://www.chessprogramming.org/One_Reply_Extensions
+//
+// forcing moves are searched one ply deeper, so that forcing lines
+// are not cut off at the horizon:
+//
+// - check extension: move gives check
+// - single reply extension: move is the only legal one
+//
+// extensions along a single line are capped by maxLineExtensions,
+// otherwise perpetual checks would make the search explode.
+
+const maxLineExtensions = 16
+
+// extension returns plies to add when searching child, one of countChildren.
+func (ab *alphaBetaState) extension(child *board, countChildren int) int {
+	if ab.lineExtensions >= maxLineExtensions {
+		return 0
+	}
+	if countChildren == 1 {
+		ab.pruneStats.singleExtensions++
+		return 1
+	}
+	if child.kingInCheck() {
+		ab.pruneStats.checkExtensions++
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExtension(t *testing.T) {
+	check, err := fenParse(strings.Fields("4k3/8/8/8/8/8/8/4RK2 b - - 0 1"))
+	if err != nil {
+		t.Fatalf("fen: %v", err)
+	}
+	quiet, err := fenParse(strings.Fields("4k3/8/8/8/8/8/8/3R1K2 b - - 0 1"))
+	if err != nil {
+		t.Fatalf("fen: %v", err)
+	}
+
+	var ab alphaBetaState
+
+	if ext := ab.extension(&check, 20); ext != 1 {
+		t.Errorf("check extension: %d", ext)
+	}
+	if ext := ab.extension(&quiet, 1); ext != 1 {
+		t.Errorf("single reply extension: %d", ext)
+	}
+	if ext := ab.extension(&quiet, 20); ext != 0 {
+		t.Errorf("quiet move extended: %d", ext)
+	}
+
+	ab.lineExtensions = maxLineExtensions
+	if ext := ab.extension(&check, 20); ext != 0 {
+		t.Errorf("line extensions not capped: %d", ext)
+	}
+
+	if ab.pruneStats.checkExtensions != 1 || ab.pruneStats.singleExtensions != 1 {
+		t.Errorf("stats: %v", ab.pruneStats)
+	}
+}
+
+// TestExtensionSearch: the line counter must be balanced after search.
+func TestExtensionSearch(t *testing.T) {
+	game := newGame()
+	game.loadFromString(b11)
+	b := game.history[len(game.history)-1]
+
+	moves := defaultMovePool
+	moves.reset()
+	ab := alphaBetaState{moves: moves}
+	rootAlphaBeta(&ab, b, 4, false)
+
+	if ab.lineExtensions != 0 {
+		t.Errorf("line extensions after search: %d", ab.lineExtensions)
+	}
+	if ab.pruneStats.checkExtensions == 0 {
+		t.Errorf("no check extension in forcing position")
+	}
+}
@@ -3,27 +3,32 @@ package main
 import (
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 	"unicode"
 )
 
 func (g gameState) showFen() {
-	fmt.Printf("fen: ")
 	last := len(g.history) - 1
-	b := g.history[last]
+	fmt.Printf("fen: %s\n", g.history[last].fen())
+}
+
+// fen returns FEN string for board.
+func (b board) fen() string {
+	var sb strings.Builder
 
 	// rows
-	showFenRow(b, 7)
+	fenRow(&sb, b, 7)
 	for row := location(6); row >= 0; row-- {
-		fmt.Print("/")
-		showFenRow(b, row)
+		sb.WriteString("/")
+		fenRow(&sb, b, row)
 	}
 
 	// turn
 	if b.turn == 0 {
-		fmt.Print(" w")
+		sb.WriteString(" w")
 	} else {
-		fmt.Print(" b")
+		sb.WriteString(" b")
 	}
 
 	// castling rights
@@ -41,41 +46,26 @@ func (g gameState) showFen() {
 		castling += "q"
 	}
 	if castling == "" {
-		fmt.Print(" -")
+		sb.WriteString(" -")
 	} else {
-		fmt.Print(" ", castling)
+		sb.WriteString(" " + castling)
 	}
 
 	// En passant target square
-	fmt.Print(" ", passantSquare(b))
+	sb.WriteString(" " + passantSquare(b))
 
-	// FIXME - Halfmove clock: This is the number of halfmoves since the last capture or pawn advance.
-	fmt.Print(" 0")
+	// Halfmove clock: This is the number of halfmoves since the last capture or pawn advance.
+	fmt.Fprint(&sb, " ", b.halfmoveClock)
 
-	// Fullmove clock
-	fmt.Print(" ", 1+(len(g.history)-1)/2)
+	// Fullmove number: starts at 1 and is incremented after black moves.
+	fmt.Fprint(&sb, " ", b.fullmove)
 
-	fmt.Println()
+	return sb.String()
 }
 
 func passantSquare(b board) string {
-	lastMove := b.lastMove
-	if !lastMove.isNull() {
-		if lastMove.rankDelta() == 2 {
-			// moved two squares
-			//dstCol := location(move[2] - 'a')
-			//dstRow := location(move[3] - '1')
-			//dstLoc := dstRow*8 + dstCol
-			dstLoc := lastMove.dst
-			dstCol := dstLoc % 8
-			dstRow := dstLoc / 8
-			p := b.square[dstLoc]
-			if p.kind() == whitePawn {
-				// it is pawn
-				targetRow := dstRow - location(colorToSignal(p.color()))
-				return coordToStr(targetRow, dstCol)
-			}
-		}
+	if target, ok := b.passantTarget(); ok {
+		return locToStr(target)
 	}
 	return "-"
 }
@@ -85,43 +75,24 @@ func abs(n int64) int64 {
 	return (n ^ y) - y
 }
 
-func showFenRow(b board, row location) {
+func fenRow(sb *strings.Builder, b board, row location) {
 	emptySquares := 0
 
-	// first column
-	loc := row*8 + 0
-	p := b.square[loc]
-	if p == pieceNone {
-		emptySquares++
-	} else {
-		fmt.Print(fenLetter(p))
-	}
-
-	for col := location(1); col < 7; col++ {
-		loc := row*8 + col
-		p := b.square[loc]
+	for col := location(0); col < 8; col++ {
+		p := b.square[row*8+col]
 		if p == pieceNone {
 			emptySquares++
-		} else {
-			if emptySquares > 0 {
-				fmt.Print(emptySquares)
-				emptySquares = 0
-			}
-			fmt.Print(fenLetter(p))
+			continue
 		}
-	}
-
-	// last column
-	loc = row*8 + 7
-	p = b.square[loc]
-	if p == pieceNone {
-		emptySquares++
-		fmt.Print(emptySquares)
-	} else {
 		if emptySquares > 0 {
-			fmt.Print(emptySquares)
+			fmt.Fprint(sb, emptySquares)
+			emptySquares = 0
 		}
-		fmt.Print(fenLetter(p))
+		sb.WriteString(fenLetter(p))
+	}
+
+	if emptySquares > 0 {
+		fmt.Fprint(sb, emptySquares)
 	}
 }
 
@@ -134,11 +105,10 @@ func fenLetter(p piece) string {
 }
 
 func fenParse(fen []string) (board, error) {
-	b := board{}
+	b := board{fullmove: 1}
 
 	// drop castling rights
-	b.flags[colorWhite] |= lostCastlingLeft | lostCastlingRight // disable castling for white
-	b.flags[colorBlack] |= lostCastlingLeft | lostCastlingRight // disable castling for black
+	b.disableCastling()
 
 	fields := len(fen)
 
@@ -177,7 +147,7 @@ func fenParse(fen []string) (board, error) {
 	}
 
 	if fen[1] != "w" {
-		b.turn = colorBlack
+		b.switchTurn()
 	}
 
 	// parse castling rights
@@ -189,15 +159,69 @@ func fenParse(fen []string) (board, error) {
 	for _, l := range fen[2] {
 		switch l {
 		case 'K':
-			b.flags[colorWhite] &= ^lostCastlingRight
+			b.setFlags(colorWhite, b.flags[colorWhite]&^lostCastlingRight)
 		case 'Q':
-			b.flags[colorWhite] &= ^lostCastlingLeft
+			b.setFlags(colorWhite, b.flags[colorWhite]&^lostCastlingLeft)
 		case 'k':
-			b.flags[colorBlack] &= ^lostCastlingRight
+			b.setFlags(colorBlack, b.flags[colorBlack]&^lostCastlingRight)
 		case 'q':
-			b.flags[colorBlack] &= ^lostCastlingLeft
+			b.setFlags(colorBlack, b.flags[colorBlack]&^lostCastlingLeft)
 		}
 	}
 
+	// parse en passant target square
+
+	if fields < 4 {
+		return b, nil // no en passant target square
+	}
+
+	if fen[3] != "-" {
+		target, errPassant := fenPassant(b, fen[3])
+		if errPassant != nil {
+			return b, errPassant
+		}
+		b.setPassant(target)
+	}
+
+	// parse halfmove clock
+
+	if fields < 5 {
+		return b, nil // no halfmove clock
+	}
+
+	// tools write all sorts of counters: never reject a position over them
+	halfmove, errHalfmove := strconv.Atoi(fen[4])
+	if errHalfmove != nil || halfmove < 0 {
+		halfmove = 0
+	}
+	b.halfmoveClock = uint8(min(halfmove, 255))
+
+	// parse fullmove number
+
+	if fields < 6 {
+		return b, nil // no fullmove number
+	}
+
+	fullmove, errFullmove := strconv.Atoi(fen[5])
+	if errFullmove != nil || fullmove < 1 {
+		fullmove = 1 // some tools write 0
+	}
+	b.fullmove = uint16(min(fullmove, 65535))
+
 	return b, nil
 }
+
+// fenPassant parses en passant target square s: the square behind
+// an opponent pawn that has just advanced two squares.
+func fenPassant(b board, s string) (location, error) {
+	targetRow := location(5 - 3*int(b.turn)) // 0=>5 1=>2
+	if len(s) != 2 || s[0] < 'a' || s[0] > 'h' || location(s[1]-'1') != targetRow {
+		return 0, fmt.Errorf("bad en passant square: %s", s)
+	}
+	target := targetRow*8 + location(s[0]-'a')
+	pawn := piece(colorInverse(b.turn)<<3) + whitePawn
+	if b.square[target-location(8*colorToSignal(b.turn))] != pawn || b.square[target] != pieceNone {
+		return 0, fmt.Errorf("bad en passant square: %s: no pawn to capture", s)
+	}
+	return target, nil
+}
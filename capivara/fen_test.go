@@ -0,0 +1,100 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFenRoundTrip(t *testing.T) {
+	fens := []string{
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1",
+		"rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3",
+		"8/8/1k6/2b5/2pP4/8/5K2/8 b - d3 0 1",
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w Kq - 7 42",
+	}
+	for _, fen := range fens {
+		b, err := fenParse(strings.Fields(fen))
+		if err != nil {
+			t.Errorf("%s: %v", fen, err)
+			continue
+		}
+		if got := b.fen(); got != fen {
+			t.Errorf("got %s expected %s", got, fen)
+		}
+		if b.zobrist != b.zobristHash() {
+			t.Errorf("%s: zobrist incremental=%016x scratch=%016x", fen, b.zobrist, b.zobristHash())
+		}
+	}
+}
+
+func TestFenBadPassant(t *testing.T) {
+	fens := []string{
+		"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e3 0 1", // wrong side to move
+		"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq d3 0 1", // no pawn to capture
+		"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq i3 0 1",
+	}
+	for _, fen := range fens {
+		if _, err := fenParse(strings.Fields(fen)); err == nil {
+			t.Errorf("%s: bad FEN accepted", fen)
+		}
+	}
+}
+
+// TestFenCounters checks odd move counters written by some tools are tolerated.
+func TestFenCounters(t *testing.T) {
+	table := []struct {
+		fen      string
+		expected string
+	}{
+		{"6k1/5ppp/8/8/8/8/5PPP/3R2K1 w - - 0 0", "6k1/5ppp/8/8/8/8/5PPP/3R2K1 w - - 0 1"},
+		{"6k1/5ppp/8/8/8/8/5PPP/3R2K1 w - - x -3", "6k1/5ppp/8/8/8/8/5PPP/3R2K1 w - - 0 1"},
+		{"6k1/5ppp/8/8/8/8/5PPP/3R2K1 w - - -1 12", "6k1/5ppp/8/8/8/8/5PPP/3R2K1 w - - 0 12"},
+		{"6k1/5ppp/8/8/8/8/5PPP/3R2K1 w - - 300 99999", "6k1/5ppp/8/8/8/8/5PPP/3R2K1 w - - 255 65535"},
+	}
+	for _, data := range table {
+		b, err := fenParse(strings.Fields(data.fen))
+		if err != nil {
+			t.Errorf("%s: %v", data.fen, err)
+			continue
+		}
+		if got := b.fen(); got != data.expected {
+			t.Errorf("%s: got %s expected %s", data.fen, got, data.expected)
+		}
+	}
+}
+
+// TestFenPassantFromMoves checks en passant square, halfmove clock and
+// fullmove number reached by playing moves match those parsed from FEN.
+func TestFenPassantFromMoves(t *testing.T) {
+	game := newGame()
+	game.loadFromString(builtinBoard)
+	for _, m := range strings.Fields("e2e4 d7d5 e4e5 f7f5") {
+		if err := game.play(m); err != nil {
+			t.Fatalf("play %s: %v", m, err)
+		}
+	}
+	played := game.history[len(game.history)-1]
+
+	const fen = "rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3"
+	if got := played.fen(); got != fen {
+		t.Errorf("got %s expected %s", got, fen)
+	}
+
+	parsed, err := fenParse(strings.Fields(fen))
+	if err != nil {
+		t.Fatalf("fen: %v", err)
+	}
+	if played.zobrist != parsed.zobrist {
+		t.Errorf("zobrist: played=%016x parsed=%016x", played.zobrist, parsed.zobrist)
+	}
+
+	moves := newMovePool()
+	for depth := 1; depth <= 3; depth++ {
+		p, _ := perft(&played, depth, moves, nil)
+		f, _ := perft(&parsed, depth, moves, nil)
+		if p != f {
+			t.Errorf("perft depth %d: played=%d parsed=%d", depth, p, f)
+		}
+	}
+}
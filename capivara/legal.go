@@ -0,0 +1,149 @@
+package main
+
+// legal move generation
+//
+// https://www.chessprogramming.org/Move_Generation#Legal
+// https://www.chessprogramming.org/Pin
+// https://www.chessprogramming.org/Check
+//
+// the bitboard generator emits only legal moves, without playing them:
+//
+// - checkers and pinned pieces are computed once per position.
+// - a pinned piece moves only along the line through it and its king.
+// - in check, pieces other than the king must capture the checker or
+//   block its ray. in double check only the king moves.
+// - the king never steps onto an attacked square. attacks are computed
+//   with the king off the board, so it cannot retreat along a checking ray.
+// - castling is never generated in check, and its squares are tested as before.
+// - en passant is rare and may uncover an attack along the rank when both
+//   pawns leave it, so it is still verified by making the move.
+//
+// the 0x88 board, used by perft only, generates pseudo-legal moves
+// and drops those leaving the king attacked. it serves as a reference.
+
+// generateLegal records legal moves for side to move.
+// returns number of moves.
+func (b *board) generateLegal(moves *movePool) int {
+	first := len(moves.pool)
+
+	b.generatePassant(moves)
+	b.keepLegalMoves(moves, first)
+
+	kingLoc := b.king[b.turn]
+	checkers := b.attackersTo(kingLoc, b.occupied()) & b.bbColor[colorInverse(b.turn)]
+
+	evasion := ^bitboard(0) // squares where a piece other than the king may land
+	switch checkers.count() {
+	case 0:
+	case 1:
+		evasion = checkers | attack.between[kingLoc][checkers.first()]
+	default:
+		evasion = 0 // double check
+	}
+
+	b.generatePieces(moves, evasion, b.pinned(kingLoc))
+
+	if checkers == 0 {
+		b.generateCastling(moves)
+	}
+
+	return len(moves.pool) - first
+}
+
+// pinned returns own pieces pinned against own king on kingLoc.
+func (b *board) pinned(kingLoc location) bitboard {
+	own := b.bbColor[b.turn]
+	them := b.bbColor[colorInverse(b.turn)]
+	queens := b.bbKind[whiteQueen]
+
+	// opponent sliders that would attack the king through own pieces
+	snipers := them & (rookAttacks(kingLoc, them)&(b.bbKind[whiteRook]|queens) |
+		bishopAttacks(kingLoc, them)&(b.bbKind[whiteBishop]|queens))
+
+	occupied := b.occupied()
+	var pinned bitboard
+	for snipers != 0 {
+		if blockers := attack.between[kingLoc][snipers.pop()] & occupied; blockers.count() == 1 {
+			pinned |= blockers & own
+		}
+	}
+	return pinned
+}
+
+// generatePieces records legal moves for every piece of side to move,
+// except en passant captures and castling.
+// pieces other than the king land only on evasion squares.
+func (b *board) generatePieces(moves *movePool, evasion, pinned bitboard) {
+	own := b.bbColor[b.turn]
+	occupied := b.occupied()
+	kingLoc := b.king[b.turn]
+
+	for pieces := own; pieces != 0; {
+		loc := pieces.pop()
+		p := b.square[loc]
+
+		allowed := evasion &^ own
+		if pinned&bit(loc) != 0 {
+			allowed &= attack.line[kingLoc][loc]
+		}
+
+		var targets bitboard
+		switch p.kind() {
+		case whitePawn:
+			b.generatePawn(moves, loc, p, occupied, allowed)
+			continue
+		case whiteKnight:
+			targets = attack.knight[loc]
+		case whiteBishop:
+			targets = bishopAttacks(loc, occupied)
+		case whiteRook:
+			targets = rookAttacks(loc, occupied)
+		case whiteQueen:
+			targets = bishopAttacks(loc, occupied) | rookAttacks(loc, occupied)
+		case whiteKing:
+			b.generateKing(moves, loc)
+			continue
+		}
+		for targets &= allowed; targets != 0; {
+			moves.push(move{src: loc, dst: targets.pop()})
+		}
+	}
+}
+
+func (b *board) generateKing(moves *movePool, loc location) {
+	them := b.bbColor[colorInverse(b.turn)]
+	occupied := b.occupied() &^ bit(loc) // king does not shield squares behind it
+
+	for targets := attack.king[loc] &^ b.bbColor[b.turn]; targets != 0; {
+		if dst := targets.pop(); b.attackersTo(dst, occupied)&them == 0 {
+			moves.push(move{src: loc, dst: dst})
+		}
+	}
+}
+
+func (b *board) generatePawn(moves *movePool, loc location, p piece, occupied, allowed bitboard) {
+	color := p.color()
+	signal := location(colorToSignal(color)) // 0=>1 1=>-1
+	lastRow := 7 - 7*location(color)         // 0=>7 1=>0
+	firstRow := 7*location(color) + signal   // 0=>1 1=>6
+
+	// can move one up/down?
+	dst := loc + 8*signal
+	if occupied&bit(dst) == 0 {
+		if allowed&bit(dst) != 0 {
+			recordPawnMove(moves, color, loc, dst, lastRow)
+		}
+
+		// can move two up/down?
+		if loc/8 == firstRow {
+			if dst2 := dst + 8*signal; occupied&bit(dst2) == 0 && allowed&bit(dst2) != 0 {
+				moves.push(move{src: loc, dst: dst2})
+			}
+		}
+	}
+
+	// captures
+	for targets := attack.pawn[color][loc] & b.bbColor[colorInverse(color)] & allowed; targets != 0; {
+		recordPawnMove(moves, color, loc, targets.pop(), lastRow)
+	}
+}
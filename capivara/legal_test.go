@@ -0,0 +1,77 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestPinned(t *testing.T) {
+	// white: bishop d2 pinned by b4, knight e2 pinned by e8, pawn f2 not pinned (two blockers on h4 diagonal)
+	b, err := fenParse(strings.Fields("k3r3/8/8/8/1b5q/6P1/3BNP2/4K3 w - - 0 1"))
+	if err != nil {
+		t.Fatalf("fen: %v", err)
+	}
+	expected := bit(8+3) | bit(8+4) // d2 e2
+	if pinned := b.pinned(b.king[b.turn]); pinned != expected {
+		t.Errorf("pinned:\n%vexpected:\n%v", pinned, expected)
+	}
+}
+
+// TestLegalEvasions compares the legal generator against
+// the 0x88 board, which filters pseudo-legal moves by making them.
+func TestLegalEvasions(t *testing.T) {
+	table := []struct {
+		fen   string
+		moves int
+	}{
+		{"4k3/8/8/8/8/2N5/3P1P2/r3K2R w K - 0 1", 3},                         // check along rank: block or step away, no castling
+		{"4k3/8/8/8/1b6/8/8/RN2K3 w - - 0 1", 6},                             // check along diagonal: block or step away
+		{"4k3/8/8/8/1b6/8/3N4/R3K3 w Q - 0 1", 15},                           // knight pinned on diagonal
+		{"4k3/8/8/8/8/5n2/8/r3K3 w - - 0 1", 2},                              // double check: king moves only
+		{"4k3/8/8/8/8/8/4q3/4K3 w - - 0 1", 1},                               // capture unprotected checker with king
+		{"8/8/8/KPp4r/8/8/8/4k3 w - c6 0 1", 4},                              // en passant would expose king along rank
+		{"8/8/8/3k4/3pP3/8/8/4K3 b - e3 0 1", 8},                             // en passant captures the checker
+		{"8/8/8/3k4/3pP3/8/8/4K2B b - e3 0 1", 6},                            // en passant captures the checker, opening a diagonal
+		{"r3k2r/8/8/8/4q3/8/8/R3K2R w KQkq - 0 1", 4},                        // check from queen, castling rights kept
+		{"rnb1kbnr/pppp1ppp/8/4p3/6Pq/5P2/PPPPP2P/RNBQKBNR w KQkq - 1 3", 0}, // checkmate
+	}
+
+	for _, data := range table {
+		b, err := fenParse(strings.Fields(data.fen))
+		if err != nil {
+			t.Fatalf("%s: fen: %v", data.fen, err)
+		}
+
+		legal := generatedMoves(&b)
+		reference := generatedMoves0x88(&b)
+
+		if legal != reference {
+			t.Errorf("%s: legal=[%s] reference=[%s]", data.fen, legal, reference)
+		}
+		if count := len(strings.Fields(legal)); count != data.moves {
+			t.Errorf("%s: moves=%d expected=%d: [%s]", data.fen, count, data.moves, legal)
+		}
+	}
+}
+
+func generatedMoves(b *board) string {
+	moves := newMovePool()
+	var list []string
+	for _, m := range moves.last(b.generateMoves(moves)) {
+		list = append(list, m.String())
+	}
+	sort.Strings(list)
+	return strings.Join(list, " ")
+}
+
+func generatedMoves0x88(b *board) string {
+	b88 := newBoard88(b)
+	moves := newMovePool88()
+	var list []string
+	for _, m := range moves.last(b88.generateMoves(moves)) {
+		list = append(list, m.move().String())
+	}
+	sort.Strings(list)
+	return strings.Join(list, " ")
+}
@@ -0,0 +1,44 @@
+package main
+
+// magic numbers for sliding attacks, see bitboard.go.
+//
+// found by findMagic, square by square from a1 to h8, rook then bishop,
+// with generator seeded by magicSeed. see TestMagicNumbers.
+
+var rookMagics = [64]uint64{
+	0x5080054001203180, 0x0040400020001000, 0x4180200180300019, 0x8100210004081000,
+	0xc600080420100200, 0x0200241200032830, 0x1480800081000200, 0x0100110003408822,
+	0x8004800020884001, 0x0000802000400088, 0x6002001604804020, 0x0802000c10420020,
+	0x0202800400080281, 0x4002800200800400, 0x2240808001000200, 0x0002002080440102,
+	0x01c0808000204006, 0x2010004020004000, 0x0830010100200040, 0x0040220040100a00,
+	0x2468004040040200, 0x40a2008080040002, 0x0005410100020004, 0x0011820001008044,
+	0xc640400080009020, 0x0040500840002000, 0x0022008200201040, 0x0222001200082040,
+	0xd004080080040080, 0x0126008080020400, 0xd014420400104801, 0x0000800180004300,
+	0x0080002000404000, 0x8100201000c00140, 0x1040200082801004, 0x000201200a004010,
+	0x0001001005000800, 0x0018040080800200, 0x0000d10a0c004810, 0x0000889442002104,
+	0x1080804000248000, 0x1000201000404000, 0x02a1004020010010, 0x8840100008008080,
+	0x4000080004008080, 0x9024000402008080, 0xa424040200010100, 0x8480074424860011,
+	0x2100800020401880, 0x2900400080200080, 0x2000188200402200, 0x2030220810010300,
+	0x2004080080040080, 0x2208800400020080, 0x440100220014b100, 0x250020a400410200,
+	0x204a102100800041, 0x0022023320830042, 0x5008402001001409, 0x0080100005002009,
+	0x000a006004081006, 0x4411000204000801, 0x0000061088104504, 0x840c010024004092,
+}
+
+var bishopMagics = [64]uint64{
+	0x4111200804802140, 0xa022100c30848020, 0x0010840040480080, 0x28024081001860e8,
+	0x08a450c020910520, 0x00950c1240011000, 0x800402080208a090, 0x0000440041101054,
+	0x62c0500410042c44, 0x089a600800808084, 0x0000041806024424, 0x8000082040480000,
+	0x0808020210040380, 0x10000a02822000b0, 0xc000020642264000, 0x0400145100982000,
+	0x5040020810810208, 0x8020840208810100, 0x4110200104008010, 0x0040840802004440,
+	0x010a004420210100, 0x0002410200522022, 0x8a04100044140401, 0x4901010201110122,
+	0x0920108184502201, 0x8182a00008480090, 0x6052500101150200, 0x0044010110200880,
+	0x0001080409004008, 0x0002020104110080, 0x0004010042a49022, 0x08084a0021010108,
+	0x0808084120481205, 0x0048080881848106, 0x0002005001944100, 0x2804400820020200,
+	0x0840084100007100, 0x00220401c1080800, 0x1081041102040108, 0x040403808809ac02,
+	0x000884200820e000, 0x8008421004021030, 0x00ac420040400401, 0x410010a214000802,
+	0xa004400408204500, 0x0c20200040414880, 0x4110020831000041, 0x2104011046114100,
+	0x00040a2104200080, 0x4008421090282200, 0x0008088068280714, 0x8200082084040800,
+	0x089000090b040004, 0xc808040830410103, 0x0069101022004100, 0x00c8420882021004,
+	0x8102020084010802, 0x000413c402480200, 0x0000002040441048, 0x0420020c11040914,
+	0x0001082010202204, 0x4009042820080894, 0x4000454802180200, 0x1820025001010010,
+}
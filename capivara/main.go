@@ -6,10 +6,8 @@ import (
 	"flag"
 	"fmt"
 	"io"
-	"math/rand"
 	"os"
 	"strings"
-	"time"
 	"unicode"
 	"unsafe"
 )
@@ -20,6 +18,15 @@ type gameState struct {
 	cpuprofile  string
 	uci         bool
 	dumbBook    bool
+	tt          *transpositionTable
+	threads     int
+	multiPV     int
+	bookSeed    int64          // re-seed book randomness on new game, 0 means never
+	control     *searchControl // non-nil while UCI search goroutine runs
+	order       *moveOrdering  // killers and history kept between iterations
+	prune       pruningFlags
+	moveGen     moveGenerator // used by perft
+	badPosition error         // last UCI position command failed: go must not search the board left behind
 }
 
 func (g *gameState) play(moveStr string) error {
@@ -49,7 +56,7 @@ func (g *gameState) play(moveStr string) error {
 }
 
 func newGame() gameState {
-	return gameState{history: []board{{}}}
+	return gameState{history: []board{{fullmove: 1}}, order: newMoveOrdering(), prune: defaultPruning, moveGen: moveGenerators[0]}
 }
 
 func (g gameState) show() {
@@ -69,6 +76,10 @@ func (g gameState) show() {
 	}
 	fmt.Println("    a  b  c  d  e  f  g  h")
 	fmt.Printf("turn: %s check: %v\n", b.turn.name(), b.kingInCheck())
+	fmt.Printf("zobrist: %016x halfmove clock: %d\n", b.zobrist, b.halfmoveClock)
+	if reason := g.drawReason(); reason != "" {
+		fmt.Printf("draw: %s\n", reason)
+	}
 
 	children := defaultBoardPool
 	children.reset()
@@ -113,13 +124,13 @@ const builtinBoard = `
     a  b  c  d  e  f  g  h
 `
 
-func (g *gameState) loadFromFen(fen []string) {
+func (g *gameState) loadFromFen(fen []string) error {
 	b, errFen := fenParse(fen)
 	if errFen != nil {
-		fmt.Printf("loadFromFen: %v\n", errFen)
-		return
+		return fmt.Errorf("loadFromFen: %v", errFen)
 	}
 	g.history = []board{b} // replace board
+	return nil
 }
 
 func (g *gameState) loadFromString(s string) {
@@ -139,7 +150,7 @@ func (g *gameState) loadFromFile(filename string) {
 
 func (g *gameState) print(s string) {
 	if g.uci {
-		fmt.Print("info capivara ")
+		fmt.Print("info string ")
 	}
 	fmt.Print(s)
 }
@@ -154,7 +165,7 @@ func (g *gameState) loadFromReader(input io.Reader) {
 	reader := bufio.NewReader(input)
 
 	var lineCount int
-	b := board{} // new board
+	b := board{fullmove: 1} // new board
 
 	for {
 		lineCount++
@@ -218,10 +229,20 @@ func main() {
 	var addChildren bool
 	var cpuprofile string
 	dumbBook := true
+	hashMB := defaultHashMB
+	prune := defaultPruning
+	threads := defaultThreads
+	generator := moveGenerators[0].String()
 
 	flag.BoolVar(&addChildren, "addChildren", addChildren, "compute number of children into evalution function")
 	flag.BoolVar(&dumbBook, "dumbBook", dumbBook, "dumb book")
 	flag.StringVar(&cpuprofile, "cpuprofile", "", "save cpuprofile into to file")
+	flag.IntVar(&hashMB, "hash", hashMB, "transposition table size in MB")
+	flag.IntVar(&threads, "threads", threads, "search threads")
+	flag.BoolVar(&prune.nullMove, "nullMove", prune.nullMove, "null-move pruning")
+	flag.BoolVar(&prune.lmr, "lmr", prune.lmr, "late move reductions")
+	flag.BoolVar(&prune.futility, "futility", prune.futility, "futility pruning")
+	flag.StringVar(&generator, "moveGen", generator, "perft move generator: bitboard or 0x88")
 	flag.BoolVar(&version, "version", false, "show version")
 	flag.Parse()
 
@@ -229,23 +250,37 @@ func main() {
 		return
 	}
 
+	moveGen, errGen := parseMoveGenerator(generator)
+	if errGen != nil {
+		fmt.Printf("%v\n", errGen)
+		os.Exit(2)
+	}
+
 	mirrorPieceSquareTable()
 
-	rand.Seed(time.Now().UnixNano())
+	seedBook(0)
 	loadBook(bufio.NewReader(strings.NewReader(defaultBook)))
 
-	gameLoop(addChildren, dumbBook, cpuprofile)
+	gameLoop(addChildren, dumbBook, cpuprofile, hashMB, threads, prune, moveGen)
 }
 
-func gameLoop(addChildren, dumbBook bool, cpuprofile string) {
+func gameLoop(addChildren, dumbBook bool, cpuprofile string, hashMB, threads int, prune pruningFlags, moveGen moveGenerator) {
 
 	game := newGame()
 	game.addChildren = addChildren
 	game.cpuprofile = cpuprofile
 	game.dumbBook = dumbBook
+	game.tt = newTranspositionTable(hashMB)
+	game.threads = max(min(threads, maxThreads), 1)
+	game.multiPV = defaultMultiPV
+	game.prune = prune
+	game.moveGen = moveGen
 	game.loadFromString(builtinBoard)
 
 	fmt.Printf("board size: %d bytes\n", unsafe.Sizeof(board{}))
+	fmt.Printf("move size: %d bytes\n", unsafe.Sizeof(move{}))
+	fmt.Printf("perft move generator: %v\n", game.moveGen)
+	fmt.Printf("transposition table: %v\n", game.tt)
 
 	input := bufio.NewReader(os.Stdin)
 LOOP:
@@ -258,6 +293,7 @@ LOOP:
 		text, errInput := input.ReadString('\n')
 		switch errInput {
 		case io.EOF:
+			game.finishSearch()
 			fmt.Println("input EOF, bye.")
 			break LOOP
 		case nil:
@@ -275,6 +311,9 @@ LOOP:
 			uciCmd := tokens[0]
 			for _, cmd := range tableUci {
 				if strings.HasPrefix(cmd.name, uciCmd) {
+					if !cmd.duringSearch {
+						game.finishSearch()
+					}
 					cmd.call(&game, tokens)
 					continue LOOP
 				}
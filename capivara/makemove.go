@@ -0,0 +1,189 @@
+package main
+
+// make/unmake
+//
+// https://www.chessprogramming.org/Make_Move
+// https://www.chessprogramming.org/Unmake_Move
+//
+// search and perft play moves on a single mutable board: makeMove returns
+// what it needs to take the move back (captured piece, castling flags,
+// halfmove clock, en passant square, previous move) and unmakeMove restores
+// the board from it. zobrist key and material are updated incrementally
+// both ways.
+//
+// the undo record is kept apart from the move, so moves stay compact in
+// move lists, killers, pv tables and history. each ply keeps its own
+// record in its frame while searching children: the call stack is the
+// undo stack.
+//
+// generateChildren, which copies a whole board per move into a boardPool,
+// is kept only as a compatibility layer for code outside search,
+// like game history.
+
+// generateMoves records legal moves for side to move into moves.
+// returns number of moves.
+func (b *board) generateMoves(moves *movePool) int {
+	return b.generateLegal(moves)
+}
+
+// keepLegalMoves drops moves, from index first on, that leave own king
+// attacked. returns number of moves kept.
+func (b *board) keepLegalMoves(moves *movePool, first int) int {
+	kept := first
+	for i := first; i < len(moves.pool); i++ {
+		m := moves.pool[i]
+		u := b.makeMove(m)
+		b.turn = colorInverse(b.turn) // look from the side that moved
+		legal := !b.kingInCheck()
+		b.turn = colorInverse(b.turn)
+		b.unmakeMove(m, u)
+		if legal {
+			moves.pool[kept] = moves.pool[i]
+			kept++
+		}
+	}
+	moves.drop(len(moves.pool) - kept)
+	return kept - first
+}
+
+// generateChildren records a child board for every legal move.
+// compatibility layer: search uses generateMoves and makeMove.
+func (b board) generateChildren(children *boardPool) int {
+	moves := &children.moves
+	countChildren := b.generateMoves(moves)
+	for _, m := range moves.last(countChildren) {
+		child := b
+		child.makeMove(m)
+		children.push(&child)
+	}
+	moves.drop(countChildren)
+	return countChildren
+}
+
+// passantTarget returns the square a pawn capturing en passant moves to,
+// if the previous move advanced a pawn by two squares.
+func (b *board) passantTarget() (location, bool) {
+	return b.passant, b.passant != 0
+}
+
+// castlingRook returns rook source and destination when king move m castles.
+func castlingRook(m move) (location, location, bool) {
+	row8 := m.src - m.src%8
+	switch m.dst - m.src {
+	case -2:
+		return row8, row8 + 3, true // A => D
+	case 2:
+		return row8 + 7, row8 + 5, true // H => F
+	}
+	return 0, 0, false
+}
+
+// undo holds what makeMove changed beyond moving pieces.
+type undo struct {
+	captured piece        // pieceNone for quiet moves
+	flags    [2]colorFlag // castling flags before the move
+	halfmove uint8        // halfmove clock before the move
+	passant  location     // en passant target square before the move
+	last     move         // previous move
+}
+
+// makeMove plays m on b, returning undo data for unmakeMove.
+func (b *board) makeMove(m move) undo {
+	u := undo{
+		captured: b.square[m.dst],
+		flags:    b.flags,
+		halfmove: b.halfmoveClock,
+		passant:  b.passant,
+		last:     b.lastMove,
+	}
+
+	b.setPassant(0) // en passant vanishes
+	b.updateHalfmoveClock(m.src, m.dst)
+
+	p := b.delPieceLoc(m.src) // take piece from board
+	color := p.color()
+
+	switch p.kind() {
+	case whitePawn:
+		switch {
+		case m.promotion != pieceNone:
+			p = piece(color<<3) + m.promotion.kind()
+		case u.passant != 0 && m.dst == u.passant:
+			u.captured = b.delPieceLoc(m.dst - location(8*colorToSignal(color))) // captured passant pawn
+		case m.rankDelta() == 2:
+			b.setPassant((m.src + m.dst) / 2) // square the pawn skipped
+		}
+	case whiteKing:
+		// king moved, then disable castling
+		b.loseCastling(color, lostCastlingLeft|lostCastlingRight)
+		if rookSrc, rookDst, castling := castlingRook(m); castling {
+			b.addPieceLoc(rookDst, b.delPieceLoc(rookSrc))
+		}
+	}
+
+	b.addPieceLoc(m.dst, p) // put piece on board, removing captured piece
+	if color == colorBlack {
+		b.fullmove++
+	}
+	b.switchTurn()
+	b.lastMove = m
+
+	return u
+}
+
+// unmakeMove takes back m, previously played on b by makeMove returning u.
+func (b *board) unmakeMove(m move, u undo) {
+	b.switchTurn()
+	if b.turn == colorBlack {
+		b.fullmove--
+	}
+
+	p := b.delPieceLoc(m.dst)
+	if m.promotion != pieceNone {
+		p = piece(b.turn<<3) + whitePawn
+	}
+	b.addPieceLoc(m.src, p)
+
+	switch {
+	case u.captured == pieceNone:
+		if p.kind() == whiteKing {
+			if rookSrc, rookDst, castling := castlingRook(m); castling {
+				b.addPieceLoc(rookSrc, b.delPieceLoc(rookDst))
+			}
+		}
+	case p.kind() == whitePawn && u.passant != 0 && m.dst == u.passant:
+		b.addPieceLoc(m.dst-location(8*colorToSignal(b.turn)), u.captured) // captured passant pawn
+	default:
+		b.addPieceLoc(m.dst, u.captured)
+	}
+
+	b.setPassant(u.passant)
+	b.setFlags(colorWhite, u.flags[colorWhite])
+	b.setFlags(colorBlack, u.flags[colorBlack])
+	b.halfmoveClock = u.halfmove
+	b.lastMove = u.last
+}
+
+// makeNullMove passes the turn without moving, returning undo data.
+func (b *board) makeNullMove() undo {
+	u := undo{halfmove: b.halfmoveClock, passant: b.passant, last: b.lastMove}
+	b.setPassant(0) // null move cancels en passant
+	b.lastMove = nullMove
+	b.tickHalfmoveClock()
+	if b.turn == colorBlack {
+		b.fullmove++
+	}
+	b.switchTurn()
+	return u
+}
+
+// unmakeNullMove takes back a null move that returned u.
+func (b *board) unmakeNullMove(u undo) {
+	b.switchTurn()
+	if b.turn == colorBlack {
+		b.fullmove--
+	}
+	b.halfmoveClock = u.halfmove
+	b.setPassant(u.passant)
+	b.lastMove = u.last
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+// TestMakeUnmake walks the tree below positions rich in castling,
+// en passant and promotion: every makeMove must keep incremental state
+// consistent, and every unmakeMove must restore the board exactly.
+func TestMakeUnmake(t *testing.T) {
+	fens := []string{
+		"r3k2r/pPppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPpP/R3K2R w KQkq - 0 1",
+		"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+		"8/8/1k6/2b5/2pP4/8/5K2/8 b - d3 0 1",
+	}
+
+	moves := newMovePool()
+	var walk func(b *board, depth int)
+	walk = func(b *board, depth int) {
+		if depth == 0 {
+			return
+		}
+		count := b.generateMoves(moves)
+		for _, m := range moves.last(count) {
+			saved := *b
+
+			u := b.makeMove(m)
+			if errSync := b.checkBitboards(); errSync != nil {
+				t.Fatalf("after %s: %v", m, errSync)
+			}
+			if b.zobrist != b.zobristHash() {
+				t.Fatalf("after %s: zobrist incremental=%016x scratch=%016x", m, b.zobrist, b.zobristHash())
+			}
+			walk(b, depth-1)
+			b.unmakeMove(m, u)
+
+			if *b != saved {
+				t.Fatalf("unmake %s did not restore board", m)
+			}
+		}
+		moves.drop(count)
+	}
+
+	for _, fen := range fens {
+		b, err := fenParse(strings.Fields(fen))
+		if err != nil {
+			t.Fatalf("fen %s: %v", fen, err)
+		}
+		walk(&b, 3)
+	}
+}
+
+// TestMoveCompact: undo data must stay out of move, which fills killers,
+// pv tables and move lists.
+func TestMoveCompact(t *testing.T) {
+	if size := unsafe.Sizeof(move{}); size != 3 {
+		t.Errorf("move size: %d bytes", size)
+	}
+}
+
+func TestGenerateChildren(t *testing.T) {
+	b, err := fenParse(strings.Fields("r3k2r/pPppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPpP/R3K2R w KQkq - 0 1"))
+	if err != nil {
+		t.Fatalf("fen: %v", err)
+	}
+
+	moves := newMovePool()
+	count := b.generateMoves(moves)
+
+	children := newPool()
+	if countChildren := b.generateChildren(children); countChildren != count {
+		t.Fatalf("got %d children expected %d", countChildren, count)
+	}
+	for i, c := range children.pool {
+		if m := moves.pool[i]; !c.lastMove.equals(m) {
+			t.Errorf("child %d: got %s expected %s", i, c.lastMove, m)
+		}
+	}
+}
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+	"unsafe"
+)
+
+// mate solver
+//
+// https://www.chessprogramming.org/Mate_Search
+//
+// proves or disproves that the side to move forces checkmate in at most n moves,
+// using only the move generator and kingInCheck: no evaluation, no pruning,
+// no draw rules. an attacking move works when every defence is met by a
+// working move one level shallower. a defender without moves is mated when
+// in check and stalemated otherwise.
+//
+// composed problems expect a single solution, so the solver reports the
+// whole solution tree: every working key move (more than one is a cook)
+// and every shortest continuation against each defence (more than one is a dual).
+//
+// the same positions are met again and again, by transposition and because
+// the tree is built by re-solving each subtree for increasing depths.
+// thus the outcome of every attacking position is kept by zobrist key:
+// the fewest moves proven to mate, and the most moves proven not to.
+// like the transposition table, the table is a fixed array indexed by key,
+// sized by the Hash option. a position replaces whatever shares its slot.
+
+// mateEntry records what is proven for the attacker to move on a position.
+type mateEntry struct {
+	mate   int32 // mates in at most this many moves, zero if unknown
+	noMate int32 // does not mate in this many moves or fewer
+}
+
+type mateSlot struct {
+	key   uint64
+	entry mateEntry
+}
+
+type mateTable struct {
+	slots []mateSlot
+	mask  uint64
+}
+
+func newMateTable(sizeMB int) *mateTable {
+	size := tableSlots(sizeMB, unsafe.Sizeof(mateSlot{}))
+	return &mateTable{slots: make([]mateSlot, size), mask: size - 1}
+}
+
+func (t *mateTable) probe(key uint64) (mateEntry, bool) {
+	slot := &t.slots[key&t.mask]
+	if slot.key != key {
+		return mateEntry{}, false
+	}
+	return slot.entry, true
+}
+
+func (t *mateTable) store(key uint64, e mateEntry) {
+	t.slots[key&t.mask] = mateSlot{key: key, entry: e}
+}
+
+type mateSolver struct {
+	moves    *movePool
+	table    *mateTable
+	control  *searchControl
+	nodes    int64
+	nextPoll int64 // poll control when nodes reach this count
+	aborted  bool
+}
+
+// mateNode is an attacking move of the solution tree.
+// no defences means the move delivers mate.
+type mateNode struct {
+	move     move
+	defences []mateDefence
+}
+
+// mateDefence is a defence against an attacking move, with
+// every continuation that mates in the fewest moves.
+type mateDefence struct {
+	move    move
+	moves   int // moves to mate after this defence
+	replies []mateNode
+}
+
+func newMateSolver(control *searchControl, sizeMB int) *mateSolver {
+	return &mateSolver{moves: newMovePool(), table: newMateTable(sizeMB), control: control}
+}
+
+func (ms *mateSolver) expired() bool {
+	if !ms.aborted && ms.nodes >= ms.nextPoll {
+		ms.nextPoll = ms.nodes + 4096
+		ms.aborted = ms.control.expired()
+	}
+	return ms.aborted
+}
+
+// attack reports whether side to move on b mates in at most n moves.
+func (ms *mateSolver) attack(b *board, n int) bool {
+	e, found := ms.table.probe(b.zobrist)
+	if found {
+		if e.mate > 0 && int(e.mate) <= n {
+			return true
+		}
+		if n <= int(e.noMate) {
+			return false
+		}
+	}
+
+	moves := ms.moves
+	countMoves := b.generateMoves(moves)
+	ms.nodes += int64(countMoves)
+	var mate bool
+	for _, m := range moves.last(countMoves) {
+		u := b.makeMove(m)
+		mate = ms.defend(b, n)
+		b.unmakeMove(m, u)
+		if mate || ms.expired() {
+			break
+		}
+	}
+	moves.drop(countMoves)
+
+	if ms.aborted && !mate {
+		return false // nothing proven
+	}
+	if mate {
+		if e.mate == 0 || n < int(e.mate) {
+			e.mate = int32(n)
+		}
+	} else {
+		e.noMate = max(e.noMate, int32(n))
+	}
+	ms.table.store(b.zobrist, e)
+	return mate
+}
+
+// defend reports whether every defence on b is met by mate in at most n-1 moves.
+// the attacker has just played the n-th move to last.
+func (ms *mateSolver) defend(b *board, n int) bool {
+	if n == 1 && !b.kingInCheck() {
+		return false // last attacking move must give check
+	}
+	moves := ms.moves
+	countMoves := b.generateMoves(moves)
+	ms.nodes += int64(countMoves)
+	if countMoves == 0 {
+		return b.kingInCheck() // checkmate, not stalemate
+	}
+	if n == 1 {
+		moves.drop(countMoves)
+		return false
+	}
+	for _, m := range moves.last(countMoves) {
+		u := b.makeMove(m)
+		mate := ms.attack(b, n-1)
+		b.unmakeMove(m, u)
+		if !mate || ms.aborted {
+			moves.drop(countMoves)
+			return false
+		}
+	}
+	moves.drop(countMoves)
+	return true
+}
+
+// shortest finds the fewest moves k <= n to mate from b, with every attacking move that mates in k.
+// it returns k=0 when there is no mate in n.
+func (ms *mateSolver) shortest(b *board, n int) ([]mateNode, int) {
+	for k := 1; k <= n && !ms.aborted; k++ {
+		if nodes := ms.solutions(b, k); len(nodes) > 0 {
+			return nodes, k
+		}
+	}
+	return nil, 0
+}
+
+// solutions expands the tree of every attacking move that mates from b in at most n moves.
+func (ms *mateSolver) solutions(b *board, n int) []mateNode {
+	moves := ms.moves
+	countMoves := b.generateMoves(moves)
+	ms.nodes += int64(countMoves)
+
+	var nodes []mateNode
+	for _, m := range moves.last(countMoves) {
+		u := b.makeMove(m)
+		if ms.defend(b, n) && !ms.aborted {
+			nodes = append(nodes, ms.expand(b, m, n))
+		}
+		b.unmakeMove(m, u)
+	}
+
+	moves.drop(countMoves)
+	return nodes
+}
+
+// expand builds the solution tree node for attacking move m, just played on b.
+func (ms *mateSolver) expand(b *board, m move, n int) mateNode {
+	moves := ms.moves
+	node := mateNode{move: m}
+	countDefences := b.generateMoves(moves)
+	for _, d := range moves.last(countDefences) {
+		u := b.makeMove(d)
+		replies, k := ms.shortest(b, n-1)
+		b.unmakeMove(d, u)
+		node.defences = append(node.defences, mateDefence{move: d, moves: k, replies: replies})
+	}
+	moves.drop(countDefences)
+	return node
+}
+
+// mateMainLine follows the most stubborn defence, then the first continuation.
+func mateMainLine(nodes []mateNode) variation {
+	var pv variation
+	for len(nodes) > 0 {
+		node := nodes[0]
+		pv = append(pv, node.move)
+		if len(node.defences) == 0 {
+			break
+		}
+		stubborn := node.defences[0]
+		for _, d := range node.defences[1:] {
+			if d.moves > stubborn.moves {
+				stubborn = d
+			}
+		}
+		pv = append(pv, stubborn.move)
+		nodes = stubborn.replies
+	}
+	return pv
+}
+
+// mateTree formats the solution tree, one move per line.
+func mateTree(nodes []mateNode) []string {
+	var lines []string
+	if len(nodes) > 1 {
+		lines = append(lines, fmt.Sprintf("cook: %d key moves", len(nodes)))
+	}
+	for _, node := range nodes {
+		lines = node.format(lines, 1, "")
+	}
+	return lines
+}
+
+func (node mateNode) format(lines []string, moveNumber int, indent string) []string {
+	if len(node.defences) == 0 {
+		return append(lines, fmt.Sprintf("%s%d. %s mate", indent, moveNumber, node.move))
+	}
+	lines = append(lines, fmt.Sprintf("%s%d. %s", indent, moveNumber, node.move))
+	for _, d := range node.defences {
+		line := fmt.Sprintf("%s  %d... %s", indent, moveNumber, d.move)
+		if len(d.replies) > 1 {
+			line += fmt.Sprintf(" dual: %d continuations", len(d.replies))
+		}
+		lines = append(lines, line)
+		for _, reply := range d.replies {
+			lines = reply.format(lines, moveNumber+1, indent+"    ")
+		}
+	}
+	return lines
+}
+
+// solveMate runs the mate solver on current position.
+// it returns the key move, or empty string when there is no mate in n.
+func (game *gameState) solveMate(control *searchControl, n int) string {
+	begin := time.Now()
+	b := game.history[len(game.history)-1]
+
+	ms := newMateSolver(control, game.hashMB())
+	nodes, k := ms.shortest(&b, n)
+	elapsed := time.Since(begin)
+
+	switch {
+	case ms.aborted:
+		game.println(fmt.Sprintf("mate: search stopped before proving mate in %d: nodes=%d elapsed=%v", n, ms.nodes, elapsed))
+		return ""
+	case k == 0:
+		game.println(fmt.Sprintf("mate: no mate in %d: nodes=%d elapsed=%v", n, ms.nodes, elapsed))
+		return ""
+	}
+
+	pv := mateMainLine(nodes)
+	if game.uci {
+		fmt.Println(uciInfo(2*k-1, len(pv), 0, mateIn(2*k-1), ms.nodes, elapsed, pv))
+	}
+	game.println(fmt.Sprintf("mate: mate in %d: keys=%d nodes=%d elapsed=%v pv=%s", k, len(nodes), ms.nodes, elapsed, pv))
+	for _, line := range mateTree(nodes) {
+		game.println(line)
+	}
+
+	return nodes[0].move.String()
+}
+
+// defaultMateTime bounds the CLI mate command, which can not be interrupted.
+const defaultMateTime = 30 * time.Second
+
+func cmdMate(_ []command, game *gameState, tokens []string) {
+	if len(tokens) < 2 {
+		fmt.Printf("usage: mate N [duration]\n")
+		return
+	}
+	n, errConv := strconv.Atoi(tokens[1])
+	if errConv != nil || n < 1 {
+		fmt.Printf("mate: bad number of moves: '%s'\n", tokens[1])
+		return
+	}
+	availTime := defaultMateTime
+	if len(tokens) > 2 {
+		a, errParse := time.ParseDuration(tokens[2])
+		if errParse != nil {
+			fmt.Printf("mate: bad duration: '%s': %v\n", tokens[2], errParse)
+			return
+		}
+		availTime = a
+	}
+	game.solveMate(newSearchControl(availTime), n)
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type mateTest struct {
+	fen   string
+	n     int
+	moves int    // expected moves to mate, 0 for no mate
+	keys  int    // expected number of key moves
+	pv    string // expected main line
+}
+
+var testMateTable = []mateTest{
+	{"r2qkb1r/pp2nppp/3p4/2pNN1B1/2BnP3/3P4/PPP2PPP/R2bK2R w KQkq - 1 1", 2, 2, 1, "d5f6 g7f6 c4f7"},
+	{"r2qkb1r/pp2nppp/3p4/2pNN1B1/2BnP3/3P4/PPP2PPP/R2bK2R w KQkq - 1 1", 1, 0, 0, ""},
+	{"k7/8/8/2K5/8/8/8/7R w - - 0 1", 3, 2, 1, "c5b6 a8b8 h1h8"}, // shortest mate is found
+	{"k7/8/1K6/8/8/8/8/6RR w - - 0 1", 1, 1, 2, "g1g8"},          // cook
+	{"7k/8/6K1/8/8/8/8/8 w - - 0 1", 3, 0, 0, ""},                // bare kings
+	{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", 2, 0, 0, ""},
+}
+
+func TestMateSolver(t *testing.T) {
+	for _, data := range testMateTable {
+		b, err := fenParse(strings.Fields(data.fen))
+		if err != nil {
+			t.Fatalf("fen %s: %v", data.fen, err)
+		}
+		ms := newMateSolver(nil, 1)
+		nodes, k := ms.shortest(&b, data.n)
+		if k != data.moves {
+			t.Errorf("%s mate in %d: got mate in %d expected %d", data.fen, data.n, k, data.moves)
+			continue
+		}
+		if len(nodes) != data.keys {
+			t.Errorf("%s mate in %d: got %d keys expected %d", data.fen, data.n, len(nodes), data.keys)
+		}
+		if pv := mateMainLine(nodes).String(); pv != data.pv {
+			t.Errorf("%s mate in %d: got pv=[%s] expected [%s]", data.fen, data.n, pv, data.pv)
+		}
+	}
+}
+
+func TestMateDual(t *testing.T) {
+	b, err := fenParse(strings.Fields("k7/8/2K5/8/8/8/8/5RR1 w - - 0 1"))
+	if err != nil {
+		t.Fatalf("fen: %v", err)
+	}
+	nodes, k := newMateSolver(nil, 1).shortest(&b, 2)
+	if k != 2 {
+		t.Fatalf("got mate in %d expected 2", k)
+	}
+
+	tree := strings.Join(mateTree(nodes), "\n")
+	for _, expected := range []string{"cook:", "1. c6b6\n  1... a8b8 dual: 2 continuations\n    2. f1f8 mate\n    2. g1g8 mate"} {
+		if !strings.Contains(tree, expected) {
+			t.Errorf("tree missing '%s':\n%s", expected, tree)
+		}
+	}
+}
+
+func TestMateStalemate(t *testing.T) {
+	// black king has no move and is not in check
+	b, err := fenParse(strings.Fields("7k/5Q2/6K1/8/8/8/8/8 b - - 0 1"))
+	if err != nil {
+		t.Fatalf("fen: %v", err)
+	}
+	if newMateSolver(nil, 1).defend(&b, 2) {
+		t.Errorf("stalemate accepted as mate")
+	}
+}
+
+// TestMateTable: proven outcomes are reused, and cut the work of later searches.
+func TestMateTable(t *testing.T) {
+	b, err := fenParse(strings.Fields("8/8/8/4k3/8/8/8/4K2R w - - 0 1"))
+	if err != nil {
+		t.Fatalf("fen: %v", err)
+	}
+	ms := newMateSolver(nil, 1)
+	if ms.attack(&b, 3) {
+		t.Fatalf("rook mates in 3 from the center")
+	}
+	if e, _ := ms.table.probe(b.zobrist); e.noMate != 3 {
+		t.Errorf("root entry: %+v", e)
+	}
+	nodes := ms.nodes
+	if ms.attack(&b, 2) || ms.nodes != nodes {
+		t.Errorf("no mate in 2 follows from no mate in 3 without search: nodes=%d", ms.nodes-nodes)
+	}
+}
+
+func TestMateDeadline(t *testing.T) {
+	b, err := fenParse(strings.Fields("8/8/8/4k3/8/8/8/4K2R w - - 0 1"))
+	if err != nil {
+		t.Fatalf("fen: %v", err)
+	}
+	ms := newMateSolver(newSearchControl(time.Millisecond), 1)
+	time.Sleep(2 * time.Millisecond)
+	if _, k := ms.shortest(&b, 20); k != 0 || !ms.aborted {
+		t.Errorf("expired search: mate in %d aborted=%v", k, ms.aborted)
+	}
+}
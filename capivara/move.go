@@ -6,16 +6,16 @@ import (
 
 var nullMove = move{}
 
+// move is compact enough to be kept in move lists instead of whole child boards.
 type move struct {
 	src       location
 	dst       location
 	promotion piece
 }
 
+// equals compares moves ignoring promotion color.
 func (m move) equals(n move) bool {
-	m.promotion = m.promotion.kind()
-	n.promotion = n.promotion.kind()
-	return m == n
+	return m.src == n.src && m.dst == n.dst && m.promotion.kind() == n.promotion.kind()
 }
 
 func newMove(s string) (move, error) {
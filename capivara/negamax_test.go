@@ -208,9 +208,11 @@ func TestB12(t *testing.T) {
 	children.reset()
 	nega := negamaxState{children: children}
 
+	// h1f1 scores the same as h1h6 at this depth,
+	// so move generation order decides which one is found first
 	score, m, _ := rootNegamax(&nega, b, 4, false)
-	if m.String() != "h1h6" {
-		t.Errorf("score: %v move: %s (expected: move h1h6)", score, m)
+	if s := m.String(); s != "h1h6" && s != "h1f1" {
+		t.Errorf("score: %v move: %s (expected: move h1h6 or h1f1)", score, m)
 	}
 }
 
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+)
+
+// move ordering
+//
+// https://www.chessprogramming.org/Move_Ordering
+//
+// moves are searched in this order:
+//
+// 1. hash move
+// 2. captures and promotions, most valuable victim first, then least valuable attacker (MVV-LVA)
+// 3. killer moves: quiet moves that caused a beta cutoff at the same ply
+// 4. remaining quiet moves by history heuristic score
+// 5. losing captures, as found by static exchange evaluation (SEE)
+
+const (
+	maxChildren = 256
+
+	orderHash    = 1 << 30
+	orderCapture = 1 << 28
+	orderKiller  = 1 << 27
+	historyMax   = orderKiller - 1 // history scores stay below killers
+)
+
+// mvvLvaValue ranks piece kinds for MVV-LVA, indexed by kind.
+var mvvLvaValue = [7]int32{
+	pieceNone:   0,
+	whiteKing:   100,
+	whiteQueen:  9,
+	whiteRook:   5,
+	whiteBishop: 3,
+	whiteKnight: 3,
+	whitePawn:   1,
+}
+
+// moveOrdering keeps killer moves and history scores across
+// iterations of iterative deepening.
+type moveOrdering struct {
+	killers [maxPly][2]move
+	history [2][64][64]int32 // [color][src][dst]
+}
+
+func newMoveOrdering() *moveOrdering {
+	return &moveOrdering{}
+}
+
+// clear forgets everything, called on new game.
+func (o *moveOrdering) clear() {
+	*o = moveOrdering{}
+}
+
+// age forgets killers and halves history, called before each new search.
+func (o *moveOrdering) age() {
+	o.killers = [maxPly][2]move{}
+	o.halveHistory()
+}
+
+func (o *moveOrdering) halveHistory() {
+	for c := range o.history {
+		for src := range o.history[c] {
+			for dst := range o.history[c][src] {
+				o.history[c][src][dst] /= 2
+			}
+		}
+	}
+}
+
+// cutoff records quiet move m that caused a beta cutoff.
+func (o *moveOrdering) cutoff(b *board, m move, depth, ply int) {
+	if o == nil || b.isNoisy(m) {
+		return
+	}
+	if ply < maxPly && !m.equals(o.killers[ply][0]) {
+		o.killers[ply][1] = o.killers[ply][0]
+		o.killers[ply][0] = m
+	}
+	h := &o.history[b.turn][m.src][m.dst]
+	*h += int32(depth * depth)
+	if *h > historyMax {
+		o.halveHistory() // keep history below killers
+	}
+}
+
+func (o *moveOrdering) isKiller(m move, ply int) bool {
+	if o == nil || ply >= maxPly {
+		return false
+	}
+	return m.equals(o.killers[ply][0]) || m.equals(o.killers[ply][1])
+}
+
+// score ranks move m about to be played on b. higher is searched first.
+func (o *moveOrdering) score(b *board, m, hashMove move, ply int) int32 {
+	if !hashMove.isNull() && m.equals(hashMove) {
+		return orderHash
+	}
+	if b.isNoisy(m) {
+		if s := b.seeCapture(m); s < 0 {
+			return int32(s) // losing capture: after all quiet moves
+		}
+		victim := b.square[m.dst].kind()
+		if victim == pieceNone && m.promotion == pieceNone {
+			victim = whitePawn // en passant
+		}
+		attacker := b.square[m.src].kind()
+		s := orderCapture + 10*mvvLvaValue[victim] - mvvLvaValue[attacker]
+		if m.promotion != pieceNone {
+			s += 10 * mvvLvaValue[m.promotion.kind()]
+		}
+		return s
+	}
+	if o == nil {
+		return 0
+	}
+	if ply < maxPly {
+		switch {
+		case m.equals(o.killers[ply][0]):
+			return orderKiller + 1
+		case m.equals(o.killers[ply][1]):
+			return orderKiller
+		}
+	}
+	return o.history[b.turn][m.src][m.dst]
+}
+
+// sortMoves orders moves of b, best candidates first.
+// nil ordering still sorts hash move and captures.
+func (o *moveOrdering) sortMoves(b *board, moves []move, hashMove move, ply int) {
+	var buf [maxChildren]int32 // enough for any legal position, avoids allocation
+	scores := buf[:]
+	if len(moves) > len(buf) {
+		scores = make([]int32, len(moves))
+	}
+	for i := range moves {
+		scores[i] = o.score(b, moves[i], hashMove, ply)
+	}
+
+	// insertion sort: stable and fast for short lists
+	for i := 1; i < len(moves); i++ {
+		s := scores[i]
+		m := moves[i]
+		j := i - 1
+		for ; j >= 0 && scores[j] < s; j-- {
+			scores[j+1] = scores[j]
+			moves[j+1] = moves[j]
+		}
+		scores[j+1] = s
+		moves[j+1] = m
+	}
+}
+
+// orderStats counts beta cutoffs to measure move ordering quality.
+type orderStats struct {
+	cutoffs      int64
+	firstCutoffs int64 // cutoffs by the first child searched
+}
+
+func (s *orderStats) add(first bool) {
+	s.cutoffs++
+	if first {
+		s.firstCutoffs++
+	}
+}
+
+// firstRate is the percentage of cutoffs produced by the first child.
+func (s orderStats) firstRate() float64 {
+	if s.cutoffs == 0 {
+		return 0
+	}
+	return 100 * float64(s.firstCutoffs) / float64(s.cutoffs)
+}
+
+func (s orderStats) String() string {
+	return fmt.Sprintf("cutoffs=%d first=%.1f%%", s.cutoffs, s.firstRate())
+}
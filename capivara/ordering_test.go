@@ -0,0 +1,87 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMoveOrdering(t *testing.T) {
+	b, err := fenParse(strings.Fields("4k3/8/8/3q2r1/2P4Q/8/8/4K3 w - - 0 1"))
+	if err != nil {
+		t.Fatalf("fen: %v", err)
+	}
+
+	order := newMoveOrdering()
+	killer, _ := newMove("e1f1")
+	hist, _ := newMove("h4h7")
+	hash, _ := newMove("e1e2")
+	order.cutoff(&b, killer, 1, 0)
+	order.history[b.turn][hist.src][hist.dst] = 100
+
+	moves := defaultMovePool
+	moves.reset()
+	count := b.generateMoves(moves)
+	order.sortMoves(&b, moves.pool[:count], hash, 0)
+
+	expected := []string{"e1e2", "c4d5", "e1f1", "h4h7"}
+	for i, e := range expected {
+		if m := moves.pool[i].String(); m != e {
+			t.Errorf("move %d: got %s expected %s", i, m, e)
+		}
+	}
+
+	// queen takes rook defended by queen: losing capture goes last
+	if m := moves.pool[count-1].String(); m != "h4g5" {
+		t.Errorf("last move: got %s expected h4g5", m)
+	}
+}
+
+func TestMoveOrderingNil(t *testing.T) {
+	b, err := fenParse(strings.Fields("4k3/8/8/3q2r1/2P4Q/8/8/4K3 w - - 0 1"))
+	if err != nil {
+		t.Fatalf("fen: %v", err)
+	}
+
+	var order *moveOrdering // captures still come first
+	order.cutoff(&b, nullMove, 1, 0)
+
+	moves := defaultMovePool
+	moves.reset()
+	count := b.generateMoves(moves)
+	order.sortMoves(&b, moves.pool[:count], nullMove, 0)
+
+	if m := moves.pool[0].String(); m != "c4d5" {
+		t.Errorf("first move: got %s expected c4d5", m)
+	}
+}
+
+// TestMoveOrderingLongList: lists beyond maxChildren are sorted too.
+func TestMoveOrderingLongList(t *testing.T) {
+	b, err := fenParse(strings.Fields("4k3/8/8/8/8/8/8/4K3 w - - 0 1"))
+	if err != nil {
+		t.Fatalf("fen: %v", err)
+	}
+	moves := make([]move, 0, maxChildren+10)
+	for len(moves) < cap(moves) {
+		moves = append(moves, move{src: location(len(moves) / 8), dst: location(40 + len(moves)%8)}) // all distinct
+	}
+	hash := moves[len(moves)-1]
+
+	var order *moveOrdering
+	order.sortMoves(&b, moves, hash, 0)
+
+	if !moves[0].equals(hash) {
+		t.Errorf("first move: got %s expected hash move %s", moves[0], hash)
+	}
+}
+
+func TestOrderStats(t *testing.T) {
+	var s orderStats
+	s.add(true)
+	s.add(true)
+	s.add(true)
+	s.add(false)
+	if r := s.firstRate(); r != 75 {
+		t.Errorf("first cutoff rate: %v", r)
+	}
+}
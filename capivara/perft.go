@@ -1,27 +1,74 @@
 package main
 
+import "fmt"
+
+// perft move generators
+//
+// perft may run on any board representation, so that backends can be
+// checked against each other and compared by benchmarks. search and
+// every other caller use the bitboard board.
+
+type moveGenerator struct {
+	name  string
+	perft func(b board, depth int, divide perftDivide) (int64, int64) // see perft
+}
+
+// perftDivide receives, for every root move, perft counts below it.
+type perftDivide func(m move, nodes, total int64)
+
+var moveGenerators = []moveGenerator{
+	{name: "bitboard", perft: perftBitboard},
+	{name: "0x88", perft: perft0x88},
+}
+
+func (g moveGenerator) String() string {
+	return g.name
+}
+
+func parseMoveGenerator(s string) (moveGenerator, error) {
+	var names []string
+	for _, g := range moveGenerators {
+		if s == g.name {
+			return g, nil
+		}
+		names = append(names, g.name)
+	}
+	return moveGenerators[0], fmt.Errorf("bad move generator: '%s' (valid: %v)", s, names)
+}
+
 var testPerftTable = []int64{0, 20, 400, 8902, 197281, 4865609, 119060324, 3195901860}
 
-func perft(b board, depth int, buf *boardPool) (int64, int64) {
+func perftBitboard(b board, depth int, divide perftDivide) (int64, int64) {
+	return perft(&b, depth, newMovePool(), divide)
+}
+
+// perft counts leaf nodes at depth, plus moves generated along the way.
+// moves are played on b with makeMove and taken back, b is left unchanged.
+// divide, if not nil, is called for every root move.
+func perft(b *board, depth int, moves *movePool, divide perftDivide) (int64, int64) {
 	if depth < 1 {
 		return 0, 0
 	}
-	countChildren := b.generateChildren(buf)
-	//log.Printf("perft +++ depth=%d children=%d pool=%d", depth, countChildren, len(buf.pool))
-	moves := int64(countChildren)
-	if depth == 1 {
-		buf.drop(countChildren)
-		//log.Printf("perft ---- depth=%d children=%d pool=%d", depth, countChildren, len(buf.pool))
-		return moves, moves
+	countMoves := b.generateMoves(moves)
+	total := int64(countMoves)
+	if depth == 1 && divide == nil {
+		moves.drop(countMoves)
+		return total, total
 	}
 	var nodes int64
-	lastChildren := buf.pool[len(buf.pool)-countChildren:]
-	for _, c := range lastChildren {
-		n, total := perft(c, depth-1, buf)
+	for _, m := range moves.last(countMoves) {
+		u := b.makeMove(m)
+		n, t := perft(b, depth-1, moves, nil)
+		b.unmakeMove(m, u)
+		if depth == 1 {
+			n = 1 // leaf
+		}
+		if divide != nil {
+			divide(m, n, t)
+		}
 		nodes += n
-		moves += total
+		total += t
 	}
-	buf.drop(countChildren)
-	//log.Printf("perft ---- depth=%d children=%d pool=%d", depth, countChildren, len(buf.pool))
-	return nodes, moves
+	moves.drop(countMoves)
+	return nodes, total
 }
@@ -6,6 +6,17 @@ import (
 )
 
 func TestPerft(t *testing.T) {
+	forEachMoveGenerator(t, testPerft)
+}
+
+// forEachMoveGenerator runs test once with every move generator backend.
+func forEachMoveGenerator(t *testing.T, test func(t *testing.T, g moveGenerator)) {
+	for _, g := range moveGenerators {
+		t.Run(g.String(), func(t *testing.T) { test(t, g) })
+	}
+}
+
+func testPerft(t *testing.T, g moveGenerator) {
 
 	game := newGame()
 	game.loadFromString(perftBoard)
@@ -17,10 +28,7 @@ func TestPerft(t *testing.T) {
 			break
 		}
 
-		buf := defaultBoardPool
-		buf.reset()
-
-		n, _ := perft(b, d, buf)
+		n, _ := g.perft(b, d, nil)
 
 		if n != nodes {
 			t.Errorf("perft depth %d: got %d nodes, expected %d", d, n, nodes)
@@ -51,6 +59,10 @@ const perftBoard = `
 `
 
 func TestPerft2(t *testing.T) {
+	forEachMoveGenerator(t, testPerft2)
+}
+
+func testPerft2(t *testing.T, g moveGenerator) {
 
 	game := newGame()
 	game.loadFromString(perftBoard2)
@@ -58,10 +70,7 @@ func TestPerft2(t *testing.T) {
 
 	d := 2
 
-	buf := defaultBoardPool
-	buf.reset()
-
-	n, _ := perft(b, d+1, buf)
+	n, _ := g.perft(b, d+1, nil)
 
 	expected := int64(33949)
 	if n != expected {
@@ -101,13 +110,17 @@ type perftFENTest struct {
 var perftFENTestTable = []perftFENTest{
 	{"Initial Position", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", testPerftTable},
 	{"Position 2", "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq -", []int64{0, 48, 2039, 97862, 4085603, 193690690, 8031647685}},
+	{"En passant capture checks opponent", "8/8/1k6/2b5/2pP4/8/5K2/8 b - d3 0 1", []int64{0, 15, 126, 1928, 13931, 206379, 1440467}},
+	{"En passant after 1. e4 d5 2. e5 f5", "rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3", []int64{0, 31, 707, 21637, 524138}},
 }
 
 func TestPerftFEN(t *testing.T) {
-	testPerftFENDepth(t, 5) // deeper will take long
+	forEachMoveGenerator(t, func(t *testing.T, g moveGenerator) {
+		testPerftFENDepth(t, g, 5) // deeper will take long
+	})
 }
 
-func testPerftFENDepth(t *testing.T, maxDepth int) {
+func testPerftFENDepth(t *testing.T, g moveGenerator, maxDepth int) {
 
 	for _, data := range perftFENTestTable {
 
@@ -116,8 +129,6 @@ func testPerftFENDepth(t *testing.T, maxDepth int) {
 		game.loadFromFen(fenTokens)
 		b := game.history[len(game.history)-1]
 
-		buf := defaultBoardPool
-
 		var depth int
 
 		for _, depthNodes := range data.expectedNodes {
@@ -128,9 +139,7 @@ func testPerftFENDepth(t *testing.T, maxDepth int) {
 				}
 			}
 
-			buf.reset()
-
-			n, _ := perft(b, depth, buf)
+			n, _ := g.perft(b, depth, nil)
 
 			if n != depthNodes {
 				t.Errorf("%s: perft maxDepth=%d depth %d: got %d nodes, expected %d", data.name, maxDepth, depth, n, depthNodes)
@@ -142,3 +151,21 @@ func testPerftFENDepth(t *testing.T, maxDepth int) {
 		}
 	}
 }
+
+func BenchmarkPerft(b *testing.B) {
+	game := newGame()
+	game.loadFromString(perftBoard)
+	brd := game.history[len(game.history)-1]
+
+	for _, g := range moveGenerators {
+		b.Run(g.String(), func(b *testing.B) {
+			var nodes int64
+			for n := 0; n < b.N; n++ {
+				nodes, _ = g.perft(brd, 4, nil)
+			}
+			testPerftNodes = nodes // record bench result to prevent the compiler from eliminating the test
+		})
+	}
+}
+
+var testPerftNodes int64
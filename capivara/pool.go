@@ -1,7 +1,8 @@
 package main
 
 type boardPool struct {
-	pool []board
+	pool  []board
+	moves movePool // scratch for generateChildren
 }
 
 var defaultBoardPool = newPool()
@@ -20,4 +21,34 @@ func (bp *boardPool) drop(n int) {
 
 func (bp *boardPool) reset() {
 	bp.pool = bp.pool[:0]
+	bp.moves.reset()
+}
+
+// movePool is a stack of move lists: each search ply pushes
+// its moves on top and drops them before returning.
+type movePool struct {
+	pool []move
+}
+
+var defaultMovePool = newMovePool()
+
+func newMovePool() *movePool {
+	return &movePool{pool: make([]move, 0, 1000)}
+}
+
+func (mp *movePool) push(m move) {
+	mp.pool = append(mp.pool, m)
+}
+
+func (mp *movePool) drop(n int) {
+	mp.pool = mp.pool[:len(mp.pool)-n]
+}
+
+func (mp *movePool) reset() {
+	mp.pool = mp.pool[:0]
+}
+
+// last returns the top n moves.
+func (mp *movePool) last(n int) []move {
+	return mp.pool[len(mp.pool)-n:]
 }
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+)
+
+// selective search
+//
+// null-move pruning: https://www.chessprogramming.org/Null_Move_Pruning
+// late move reductions: https://www.chessprogramming.org/Late_Move_Reductions
+// futility pruning: https://www.chessprogramming.org/Futility_Pruning
+// reverse futility pruning: https://www.chessprogramming.org/Reverse_Futility_Pruning
+//
+// none of them is applied at PV nodes or when the side to move is in check.
+
+// pruningFlags switches each technique on or off, so they can be compared in matches.
+type pruningFlags struct {
+	nullMove bool
+	lmr      bool
+	futility bool
+}
+
+var defaultPruning = pruningFlags{nullMove: true, lmr: true, futility: true}
+
+const (
+	nullMoveMinDepth = 3 // do not try null move near leaves
+
+	lmrMinDepth = 3 // do not reduce near leaves
+	lmrMinMoves = 3 // never reduce the first moves in the ordering
+
+	futilityMaxDepth      = 3
+	reverseFutilityMargin = 120 // centipawns per remaining ply
+)
+
+// futilityMargin is the largest gain expected from a quiet move, indexed by remaining depth.
+var futilityMargin = [futilityMaxDepth + 1]int{0, 200, 300, 500}
+
+// nullMoveReduction: R=2, or R=3 for deep searches.
+func nullMoveReduction(depth int) int {
+	if depth > 6 {
+		return 3
+	}
+	return 2
+}
+
+// lmrReduction reduces later moves further.
+func lmrReduction(depth, moveNumber int) int {
+	if depth >= 6 && moveNumber >= 6 {
+		return 2
+	}
+	return 1
+}
+
+// hasNonPawnMaterial guards null move against zugzwang:
+// positions with only king and pawns are zugzwang-prone.
+func (b *board) hasNonPawnMaterial(color pieceColor) bool {
+	for _, p := range b.square {
+		if p == pieceNone || p.color() != color {
+			continue
+		}
+		switch p.kind() {
+		case whiteQueen, whiteRook, whiteBishop, whiteKnight:
+			return true
+		}
+	}
+	return false
+}
+
+// pruneStats counts how often each technique fired.
+type pruneStats struct {
+	nullCutoffs     int64
+	reductions      int64
+	futility        int64
+	reverseFutility int64
+
+	checkExtensions  int64
+	singleExtensions int64
+}
+
+func (s *pruneStats) add(other pruneStats) {
+	s.nullCutoffs += other.nullCutoffs
+	s.reductions += other.reductions
+	s.futility += other.futility
+	s.reverseFutility += other.reverseFutility
+	s.checkExtensions += other.checkExtensions
+	s.singleExtensions += other.singleExtensions
+}
+
+func (s pruneStats) String() string {
+	return fmt.Sprintf("nullcuts=%d reductions=%d futility=%d rfp=%d checkext=%d singleext=%d",
+		s.nullCutoffs, s.reductions, s.futility, s.reverseFutility, s.checkExtensions, s.singleExtensions)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNullMove(t *testing.T) {
+	game := newGame()
+	game.loadFromString(builtinBoard)
+	if err := game.play("e2e4"); err != nil {
+		t.Fatalf("play: %v", err)
+	}
+	b := game.history[len(game.history)-1]
+	null := b
+
+	u := null.makeNullMove()
+	if null.turn == b.turn {
+		t.Errorf("null move must switch turn")
+	}
+	if !null.lastMove.isNull() {
+		t.Errorf("null move must clear last move: %s", null.lastMove)
+	}
+	if null.zobrist != null.zobristHash() {
+		t.Errorf("null move zobrist: incremental=%016x scratch=%016x", null.zobrist, null.zobristHash())
+	}
+	if null.halfmoveClock != b.halfmoveClock+1 {
+		t.Errorf("null move halfmove clock: %d", null.halfmoveClock)
+	}
+
+	null.unmakeNullMove(u)
+	if null != b {
+		t.Errorf("unmake null move did not restore board")
+	}
+}
+
+func TestHasNonPawnMaterial(t *testing.T) {
+	b, err := fenParse(strings.Fields("4k3/pppp4/8/8/8/8/4P3/3NK3 w - - 0 1"))
+	if err != nil {
+		t.Fatalf("fen: %v", err)
+	}
+	if !b.hasNonPawnMaterial(colorWhite) {
+		t.Errorf("white has a knight")
+	}
+	if b.hasNonPawnMaterial(colorBlack) {
+		t.Errorf("black has only pawns")
+	}
+}
+
+// TestPruning: selective search must keep finding mate and search fewer nodes.
+func TestPruning(t *testing.T) {
+	game := newGame()
+	game.loadFromString(b11)
+	b := game.history[len(game.history)-1]
+
+	moves := defaultMovePool
+	moves.reset()
+	ab := alphaBetaState{moves: moves, prune: defaultPruning, order: newMoveOrdering()}
+	score, pv, _ := rootAlphaBeta(&ab, b, 4, false)
+	if score != mateIn(1) {
+		t.Errorf("mate in 1 not found: score=%d pv=[%s]", score, pv)
+	}
+
+	game.loadFromString(builtinBoard)
+	b = game.history[len(game.history)-1]
+
+	depth := 5
+
+	moves.reset()
+	full := alphaBetaState{moves: moves, order: newMoveOrdering()}
+	rootAlphaBeta(&full, b, depth, false)
+
+	for _, prune := range []pruningFlags{{nullMove: true}, {lmr: true}, {futility: true}, defaultPruning} {
+		moves.reset()
+		ab := alphaBetaState{moves: moves, prune: prune, order: newMoveOrdering()}
+		_, pv, _ := rootAlphaBeta(&ab, b, depth, false)
+		if pv.best().isNull() {
+			t.Errorf("%+v: no move", prune)
+		}
+		if ab.nodes >= full.nodes {
+			t.Errorf("%+v: nodes=%d full width nodes=%d", prune, ab.nodes, full.nodes)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import "strings"
+
+// principal variation
+//
+// https://www.chessprogramming.org/Triangular_PV-Table
+//
+// line[ply] holds the best line found from ply onwards.
+// whenever a move raises alpha at ply, the line for ply
+// becomes that move followed by the line for ply+1.
+
+const maxPly = 128
+
+type pvTable struct {
+	line   [maxPly][maxPly]move
+	length [maxPly]int
+}
+
+// clear forgets the line for ply, called when a node is entered.
+func (t *pvTable) clear(ply int) {
+	if ply < maxPly {
+		t.length[ply] = 0
+	}
+}
+
+// update records m as best move for ply, followed by the line for ply+1.
+func (t *pvTable) update(ply int, m move) {
+	if ply >= maxPly-1 {
+		return
+	}
+	t.line[ply][0] = m
+	n := copy(t.line[ply][1:], t.line[ply+1][:t.length[ply+1]])
+	t.length[ply] = n + 1
+}
+
+// variation returns a copy of the root line.
+func (t *pvTable) variation() variation {
+	return append(variation(nil), t.line[0][:t.length[0]]...)
+}
+
+type variation []move
+
+// best returns the first move in the line.
+func (v variation) best() move {
+	if len(v) < 1 {
+		return nullMove
+	}
+	return v[0]
+}
+
+func (v variation) String() string {
+	moves := make([]string, 0, len(v))
+	for _, m := range v {
+		moves = append(moves, m.String())
+	}
+	return strings.Join(moves, " ")
+}
@@ -0,0 +1,84 @@
+package main
+
+import "testing"
+
+func TestPrincipalVariation(t *testing.T) {
+	for _, brd := range []string{b2, b7, b9} {
+		game := newGame()
+		game.loadFromString(brd)
+		b := game.history[len(game.history)-1]
+
+		depth := 4
+
+		moves := defaultMovePool
+		moves.reset()
+		ab := alphaBetaState{moves: moves}
+
+		score, pv, _ := rootAlphaBeta(&ab, b, depth, false)
+
+		// check extensions may make the line longer than depth
+		if len(pv) < depth || len(pv) > depth+maxLineExtensions {
+			t.Errorf("score=%v pv=[%s]: length=%d expected=%d", score, pv, len(pv), depth)
+		}
+
+		// every move in the line must be valid
+		for _, m := range pv {
+			if errPlay := game.play(m.String()); errPlay != nil {
+				t.Errorf("pv=[%s]: %v", pv, errPlay)
+				break
+			}
+		}
+	}
+}
+
+func TestPrincipalVariationTable(t *testing.T) {
+	var table pvTable
+
+	m1 := move{src: 12, dst: 28}
+	m2 := move{src: 52, dst: 36}
+	m3 := move{src: 6, dst: 21}
+
+	table.clear(2)
+	table.update(2, m3)
+	table.clear(1)
+	table.update(1, m2)
+	table.clear(0)
+	table.update(0, m1)
+
+	if pv := table.variation(); pv.String() != "e2e4 e7e5 g1f3" {
+		t.Errorf("pv=[%s] expected=[e2e4 e7e5 g1f3]", pv)
+	}
+
+	// a new best move at ply 1 with empty continuation truncates the line
+	table.clear(2)
+	table.update(1, m3)
+	table.update(0, m1)
+
+	if pv := table.variation(); pv.String() != "e2e4 g1f3" {
+		t.Errorf("pv=[%s] expected=[e2e4 g1f3]", pv)
+	}
+}
+
+// TestPrincipalVariationSecondSearch checks a search repeated over a
+// warm transposition table still reports the whole line,
+// not only the moves before the first table hit.
+func TestPrincipalVariationSecondSearch(t *testing.T) {
+	game := newGame()
+	game.loadFromString(builtinBoard)
+	b := game.history[len(game.history)-1]
+
+	depth := 5
+	tt := newTranspositionTable(1)
+
+	for search := 1; search <= 2; search++ {
+		moves := defaultMovePool
+		moves.reset()
+		ab := alphaBetaState{moves: moves, tt: tt}
+
+		score, pv, _ := rootAlphaBeta(&ab, b, depth, false)
+
+		if len(pv) < depth {
+			t.Errorf("search=%d score=%v pv=[%s]: length=%d expected=%d", search, score, pv, len(pv), depth)
+		}
+	}
+}
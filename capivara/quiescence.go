@@ -0,0 +1,82 @@
+package main
+
+// quiescence search
+//
+// https://www.chessprogramming.org/Quiescence_Search
+//
+// at the leaves of alpha-beta search, keep searching captures and
+// promotions until the position is quiet, so that the static evaluation
+// is not taken in the middle of an exchange (horizon effect).
+// the side to move may decline to capture (stand pat), unless in check:
+// then every evasion is searched, so that mate at the horizon is seen.
+func quiescence(ab *alphaBetaState, b *board, alpha, beta int, ply int, addChildren bool) int {
+
+	moves := ab.moves
+
+	if ply > ab.seldepth {
+		ab.seldepth = ply
+	}
+
+	inCheck := b.kingInCheck()
+
+	if !inCheck {
+		standPat := evaluate(moves, b, addChildren)
+		if standPat >= beta {
+			return beta
+		}
+		if standPat > alpha {
+			alpha = standPat
+		}
+	}
+
+	countMoves := b.generateMoves(moves)
+	if countMoves == 0 {
+		if inCheck {
+			return matedIn(ply) // checkmated
+		}
+		return 0 // draw
+	}
+
+	ab.nodes += int64(countMoves)
+
+	lastMoves := moves.last(countMoves)
+
+	ab.order.sortMoves(b, lastMoves, nullMove, ply) // MVV-LVA
+
+	for _, m := range lastMoves {
+		if !inCheck {
+			if !b.isNoisy(m) {
+				continue // quiet move
+			}
+			if b.seeCapture(m) < 0 {
+				continue // SEE pruning: exchange loses material
+			}
+		}
+		u := b.makeMove(m)
+		score := quiescence(ab, b, -beta, -alpha, ply+1, addChildren)
+		b.unmakeMove(m, u)
+		score = -score
+		if score >= beta {
+			moves.drop(countMoves)
+			return beta
+		}
+		if score > alpha {
+			alpha = score
+		}
+	}
+
+	moves.drop(countMoves)
+	return alpha
+}
+
+// isNoisy reports whether move m (about to be played on b) is a capture or promotion.
+func (b *board) isNoisy(m move) bool {
+	if m.promotion != pieceNone {
+		return true
+	}
+	if b.square[m.dst] != pieceNone {
+		return true // capture
+	}
+	// en passant: pawn moves diagonally into empty square
+	return b.square[m.src].kind() == whitePawn && m.src%8 != m.dst%8
+}
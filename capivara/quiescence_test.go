@@ -0,0 +1,188 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestQ1Horizon shows the horizon effect without quiescence:
+// plain depth 1 search grabs the defended pawn with the queen.
+func TestQ1Horizon(t *testing.T) {
+	game := newGame()
+	game.loadFromString(q1)
+	last := len(game.history) - 1
+	b := game.history[last]
+	b.disableCastling()
+
+	children := defaultBoardPool
+	children.reset()
+	nega := negamaxState{children: children}
+
+	score, m, _ := rootNegamax(&nega, b, 1, false)
+	if m.String() != "d1d5" {
+		t.Errorf("score: %v move: %s (expected: horizon blunder d1d5)", score, m)
+	}
+}
+
+func TestQ1(t *testing.T) {
+	game := newGame()
+	game.loadFromString(q1)
+	last := len(game.history) - 1
+	b := game.history[last]
+	b.disableCastling()
+
+	for depth := 1; depth <= 3; depth++ {
+		moves := defaultMovePool
+		moves.reset()
+		ab := alphaBetaState{moves: moves}
+
+		score, pv, _ := rootAlphaBeta(&ab, b, depth, false)
+		m := pv.best()
+		if m.String() == "d1d5" {
+			t.Errorf("depth=%d score: %v move: %s (expected: queen must not take defended pawn)", depth, score, m)
+		}
+	}
+}
+
+func TestQ2(t *testing.T) {
+	game := newGame()
+	game.loadFromString(q2)
+	last := len(game.history) - 1
+	b := game.history[last]
+	b.disableCastling()
+
+	for depth := 1; depth <= 3; depth++ {
+		moves := defaultMovePool
+		moves.reset()
+		ab := alphaBetaState{moves: moves}
+
+		score, pv, _ := rootAlphaBeta(&ab, b, depth, false)
+		m := pv.best()
+		if m.String() == "a4e4" {
+			t.Errorf("depth=%d score: %v move: %s (expected: rook must not take defended knight)", depth, score, m)
+		}
+	}
+}
+
+func TestQ3(t *testing.T) {
+	game := newGame()
+	game.loadFromString(q3)
+	last := len(game.history) - 1
+	b := game.history[last]
+	b.disableCastling()
+
+	for depth := 1; depth <= 3; depth++ {
+		moves := defaultMovePool
+		moves.reset()
+		ab := alphaBetaState{moves: moves}
+
+		score, pv, _ := rootAlphaBeta(&ab, b, depth, false)
+		m := pv.best()
+		if m.String() != "a4e4" {
+			t.Errorf("depth=%d score: %v move: %s (expected: rook takes hanging knight a4e4)", depth, score, m)
+		}
+	}
+}
+
+func TestQuiescenceStandPat(t *testing.T) {
+	game := newGame()
+	game.loadFromString(q1)
+	last := len(game.history) - 1
+	b := game.history[last]
+	b.disableCastling()
+
+	moves := defaultMovePool
+	moves.reset()
+	ab := alphaBetaState{moves: moves}
+
+	// white should not lose material by capturing
+	score := quiescence(&ab, &b, alphabetaMin, alphabetaMax, 0, false)
+	standPat := evaluate(moves, &b, false)
+	if score != standPat {
+		t.Errorf("quiescence score: %v (expected stand pat: %v)", score, standPat)
+	}
+}
+
+const q1 = `
+    a  b  c  d  e  f  g  h
+   -------------------------
+8  |  |  |  |  |.K|  |  |  |  8
+   -------------------------
+7  |  |  |  |  |  |  |  |  |  7
+   -------------------------
+6  |  |  |.p|  |  |  |  |  |  6
+   -------------------------
+5  |  |  |  |.p|  |  |  |  |  5
+   -------------------------
+4  |  |  |  |  |  |  |  |  |  4
+   -------------------------
+3  |  |  |  |  |  |  |  |  |  3
+   -------------------------
+2  |  |  |  |  |  |  |  |  |  2
+   -------------------------
+1  |  |  |  |*Q|*K|  |  |  |  1
+   -------------------------
+    a  b  c  d  e  f  g  h
+`
+
+const q2 = `
+    a  b  c  d  e  f  g  h
+   -------------------------
+8  |  |  |  |  |.K|  |  |  |  8
+   -------------------------
+7  |  |  |  |  |  |  |  |  |  7
+   -------------------------
+6  |  |  |  |  |  |  |  |  |  6
+   -------------------------
+5  |  |  |  |  |  |.p|  |  |  5
+   -------------------------
+4  |*R|  |  |  |.N|  |  |  |  4
+   -------------------------
+3  |  |  |  |  |  |  |  |  |  3
+   -------------------------
+2  |  |  |  |  |  |  |  |  |  2
+   -------------------------
+1  |  |  |  |  |  |  |*K|  |  1
+   -------------------------
+    a  b  c  d  e  f  g  h
+`
+
+const q3 = `
+    a  b  c  d  e  f  g  h
+   -------------------------
+8  |  |  |  |  |.K|  |  |  |  8
+   -------------------------
+7  |  |  |  |  |  |  |  |  |  7
+   -------------------------
+6  |  |  |  |  |  |  |  |  |  6
+   -------------------------
+5  |  |  |  |  |  |  |  |  |  5
+   -------------------------
+4  |*R|  |  |  |.N|  |  |  |  4
+   -------------------------
+3  |  |  |  |  |  |  |  |  |  3
+   -------------------------
+2  |  |  |  |  |  |  |  |  |  2
+   -------------------------
+1  |  |  |  |  |  |  |*K|  |  1
+   -------------------------
+    a  b  c  d  e  f  g  h
+`
+
+// TestQuiescenceMate checks mate delivered at the horizon is not hidden
+// by the stand pat score of the mated side.
+func TestQuiescenceMate(t *testing.T) {
+	b, err := fenParse(strings.Fields("6k1/5ppp/8/8/8/8/5PPP/3R2K1 w - - 0 1"))
+	if err != nil {
+		t.Fatalf("fen: %v", err)
+	}
+
+	moves := defaultMovePool
+	moves.reset()
+	ab := alphaBetaState{moves: moves}
+
+	score, pv, _ := rootAlphaBeta(&ab, b, 1, false)
+	if m := pv.best(); m.String() != "d1d8" || score != mateIn(1) {
+		t.Errorf("score: %v move: %s (expected: mate d1d8 score %v)", score, m, mateIn(1))
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+// draw detection
+//
+// https://www.chessprogramming.org/Repetitions
+// https://www.chessprogramming.org/Fifty-move_Rule
+//
+// only positions reached since the last irreversible move
+// (capture or pawn advance) can repeat, thus the scan
+// is limited by the halfmove clock.
+
+const fiftyMoveLimit = 100 // halfmoves
+
+// countRepetitions counts occurrences of key in path among the
+// last halfmoveClock positions with the same side to move.
+// path holds keys for ancestors of the position (parent last).
+func countRepetitions(path []uint64, key uint64, halfmoveClock uint8) int {
+	var count int
+	n := len(path)
+	limit := n - int(halfmoveClock)
+	for i := n - 2; i >= 0 && i >= limit; i -= 2 {
+		if path[i] == key {
+			count++
+		}
+	}
+	return count
+}
+
+// isDraw checks the fifty-move rule and repetitions within game history plus search path.
+// a single repetition is enough to score a position as draw during search.
+func (ab *alphaBetaState) isDraw(b *board) bool {
+	if b.halfmoveClock >= fiftyMoveLimit {
+		return true
+	}
+	return countRepetitions(ab.path, b.zobrist, b.halfmoveClock) > 0
+}
+
+func (ab *alphaBetaState) pushPath(b *board) {
+	ab.path = append(ab.path, b.zobrist)
+}
+
+func (ab *alphaBetaState) popPath() {
+	ab.path = ab.path[:len(ab.path)-1]
+}
+
+// historyPath returns keys for game history, excluding the current position.
+func (g *gameState) historyPath() []uint64 {
+	last := len(g.history) - 1
+	path := make([]uint64, 0, last+64)
+	for _, b := range g.history[:last] {
+		path = append(path, b.zobrist)
+	}
+	return path
+}
+
+// drawReason reports whether the current game position is drawn by rule.
+func (g *gameState) drawReason() string {
+	last := len(g.history) - 1
+	b := g.history[last]
+	if b.halfmoveClock >= fiftyMoveLimit {
+		return "fifty-move rule"
+	}
+	if countRepetitions(g.historyPath(), b.zobrist, b.halfmoveClock) >= 2 {
+		return "threefold repetition"
+	}
+	return ""
+}
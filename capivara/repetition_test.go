@@ -0,0 +1,106 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHalfmoveClock(t *testing.T) {
+	game := newGame()
+	game.loadFromString(builtinBoard)
+
+	if _, err := game.validatePosition("g1f3 g8f6 f3g1 f6g8"); err != nil {
+		t.Fatalf("play: %v", err)
+	}
+	if clock := game.history[len(game.history)-1].halfmoveClock; clock != 4 {
+		t.Errorf("halfmove clock after knight moves: %d (expected 4)", clock)
+	}
+
+	if _, err := game.validatePosition("e2e4"); err != nil {
+		t.Fatalf("play: %v", err)
+	}
+	if clock := game.history[len(game.history)-1].halfmoveClock; clock != 0 {
+		t.Errorf("halfmove clock after pawn move: %d (expected 0)", clock)
+	}
+
+	if _, err := game.validatePosition("d7d5 b1c3 g8f6 e4d5"); err != nil {
+		t.Fatalf("play: %v", err)
+	}
+	if clock := game.history[len(game.history)-1].halfmoveClock; clock != 0 {
+		t.Errorf("halfmove clock after capture: %d (expected 0)", clock)
+	}
+}
+
+func TestHalfmoveClockFEN(t *testing.T) {
+	b, err := fenParse(strings.Fields("8/8/4k3/8/8/4K3/8/7R w - - 37 80"))
+	if err != nil {
+		t.Fatalf("fen: %v", err)
+	}
+	if b.halfmoveClock != 37 {
+		t.Errorf("halfmove clock from FEN: %d (expected 37)", b.halfmoveClock)
+	}
+}
+
+func TestThreefoldRepetition(t *testing.T) {
+	game := newGame()
+	game.loadFromString(builtinBoard)
+
+	if _, err := game.validatePosition("g1f3 g8f6 f3g1 f6g8"); err != nil {
+		t.Fatalf("play: %v", err)
+	}
+	if reason := game.drawReason(); reason != "" {
+		t.Errorf("unexpected draw after twofold repetition: %s", reason)
+	}
+
+	if _, err := game.validatePosition("g1f3 g8f6 f3g1 f6g8"); err != nil {
+		t.Fatalf("play: %v", err)
+	}
+	if reason := game.drawReason(); reason != "threefold repetition" {
+		t.Errorf("draw reason: '%s' (expected: threefold repetition)", reason)
+	}
+}
+
+func TestFiftyMoveRule(t *testing.T) {
+	game := newGame()
+	game.loadFromFen(strings.Fields("8/8/4k3/8/8/4K3/8/7R w - - 99 80"))
+
+	if reason := game.drawReason(); reason != "" {
+		t.Errorf("unexpected draw: %s", reason)
+	}
+
+	if errPlay := game.play("h1h2"); errPlay != nil {
+		t.Fatalf("play: %v", errPlay)
+	}
+	if reason := game.drawReason(); reason != "fifty-move rule" {
+		t.Errorf("draw reason: '%s' (expected: fifty-move rule)", reason)
+	}
+}
+
+// TestRepetitionLosingSide: black is losing material and
+// takes the repetition to score a draw.
+func TestRepetitionLosingSide(t *testing.T) {
+	game := newGame()
+	game.loadFromFen(strings.Fields("4k3/8/8/8/4n3/8/8/R5K1 b - - 0 1"))
+
+	// pretend position after e8d8 was already seen in this game
+	if errPlay := game.play("e8d8"); errPlay != nil {
+		t.Fatalf("play: %v", errPlay)
+	}
+	repeated := game.history[len(game.history)-1]
+	game.undo()
+
+	// history: repeated position, then current position (pushed by search)
+	path := []uint64{repeated.zobrist}
+	b := game.history[0]
+	b.halfmoveClock = 10
+
+	moves := defaultMovePool
+	moves.reset()
+	ab := alphaBetaState{moves: moves, path: path}
+
+	score, pv, _ := rootAlphaBeta(&ab, b, 2, false)
+	m := pv.best()
+	if m.String() != "e8d8" || score != 0 {
+		t.Errorf("score: %v move: %s (expected: draw by repetition e8d8 score=0)", score, m)
+	}
+}
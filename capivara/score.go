@@ -0,0 +1,76 @@
+package main
+
+// alpha-beta scores
+//
+// https://www.chessprogramming.org/Score
+// https://www.chessprogramming.org/Checkmate#MateScores
+//
+// scores are integer centipawns relative to the side to move.
+// mate is encoded as scoreMate minus the distance in plies from root,
+// so that a shorter mate always scores better than a longer one:
+//
+// mateIn(ply)  = scoreMate - ply  (side to move at root delivers mate at ply)
+// matedIn(ply) = ply - scoreMate  (side to move at root is mated at ply)
+
+const (
+	scoreMate      = 30000
+	scoreMateBound = scoreMate - maxPly // any score beyond this is a mate score
+
+	alphabetaMin = -scoreMate - 1
+	alphabetaMax = scoreMate + 1
+)
+
+func mateIn(ply int) int {
+	return scoreMate - ply
+}
+
+func matedIn(ply int) int {
+	return ply - scoreMate
+}
+
+func isMateScore(score int) bool {
+	return score >= scoreMateBound || score <= -scoreMateBound
+}
+
+// mateMoves converts a mate score into moves to mate:
+// positive when side to move mates, negative when it is mated.
+func mateMoves(score int) int {
+	if score > 0 {
+		return (scoreMate - score + 1) / 2
+	}
+	return -(scoreMate + score) / 2
+}
+
+// scoreToTT converts mate score from distance-to-root into distance-to-node,
+// since the same position may be reached at different plies.
+func scoreToTT(score, ply int) int {
+	switch {
+	case score >= scoreMateBound:
+		return score + ply
+	case score <= -scoreMateBound:
+		return score - ply
+	}
+	return score
+}
+
+// scoreFromTT converts mate score from distance-to-node back into distance-to-root.
+func scoreFromTT(score, ply int) int {
+	switch {
+	case score >= scoreMateBound:
+		return score - ply
+	case score <= -scoreMateBound:
+		return score + ply
+	}
+	return score
+}
+
+// evaluate returns static score in centipawns relative to side to move.
+func evaluate(moves *movePool, b *board, addChildren bool) int {
+	score := colorToSignal(b.turn) * (int(b.materialValue[colorWhite]) + int(b.materialValue[colorBlack]))
+	if addChildren {
+		countMoves := b.generateMoves(moves)
+		score += countMoves // one centipawn per legal move
+		moves.drop(countMoves)
+	}
+	return score
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// maxSearchDepth bounds iterative deepening when there is no deadline.
+const maxSearchDepth = 64
+
+// searchControl lets a running search be stopped from another goroutine.
+//
+// the search polls expired() while it scans children.
+// stop() and ponderhit() are called by the UCI input loop.
+type searchControl struct {
+	stopped  atomic.Bool
+	deadline atomic.Int64 // unix nanoseconds, zero means no deadline
+	start    atomic.Int64 // unix nanoseconds when deadline was armed, zero means not armed
+
+	// infinite and ponder searches must not report bestmove
+	// before the GUI sends stop (or ponderhit).
+	hold        bool
+	release     chan struct{}
+	releaseOnce sync.Once
+
+	// budget applied to deadline on ponderhit
+	ponderBudget time.Duration
+
+	done chan struct{} // closed when search goroutine exits
+
+	parent *searchControl // optional: expires together with parent
+}
+
+func newSearchControl(perMove time.Duration) *searchControl {
+	c := &searchControl{
+		release: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	if perMove > 0 {
+		c.armDeadline(perMove)
+	}
+	return c
+}
+
+// armDeadline starts the clock now, giving the search budget to run.
+func (c *searchControl) armDeadline(budget time.Duration) {
+	now := time.Now()
+	c.start.Store(now.UnixNano())
+	c.setDeadline(now.Add(budget))
+}
+
+// startTime returns when the clock started, zero if never armed.
+func (c *searchControl) startTime() time.Time {
+	s := c.start.Load()
+	if s == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, s)
+}
+
+func (c *searchControl) setDeadline(t time.Time) {
+	c.deadline.Store(t.UnixNano())
+}
+
+func (c *searchControl) getDeadline() time.Time {
+	d := c.deadline.Load()
+	if d == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, d)
+}
+
+// expired reports whether the search must stop now.
+// nil control never expires.
+func (c *searchControl) expired() bool {
+	if c == nil {
+		return false
+	}
+	if c.stopped.Load() || c.parent.expired() {
+		return true
+	}
+	d := c.deadline.Load()
+	return d != 0 && time.Now().UnixNano() > d
+}
+
+func (c *searchControl) stop() {
+	c.stopped.Store(true)
+	c.doRelease()
+}
+
+// ponderhit switches a ponder search into a regular timed search.
+func (c *searchControl) ponderhit() {
+	if c.ponderBudget > 0 {
+		c.armDeadline(c.ponderBudget)
+	}
+	c.doRelease()
+}
+
+func (c *searchControl) doRelease() {
+	c.releaseOnce.Do(func() { close(c.release) })
+}
+
+// waitRelease blocks infinite and ponder searches until stop or ponderhit.
+func (c *searchControl) waitRelease() {
+	if c.hold {
+		<-c.release
+	}
+}
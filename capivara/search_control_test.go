@@ -0,0 +1,69 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSearchControlExpired(t *testing.T) {
+	var none *searchControl
+	if none.expired() {
+		t.Errorf("nil control should never expire")
+	}
+
+	infinite := newSearchControl(0)
+	if infinite.expired() {
+		t.Errorf("control without deadline expired")
+	}
+	infinite.stop()
+	if !infinite.expired() {
+		t.Errorf("stopped control should expire")
+	}
+
+	timed := newSearchControl(time.Millisecond)
+	time.Sleep(2 * time.Millisecond)
+	if !timed.expired() {
+		t.Errorf("control should expire after deadline")
+	}
+}
+
+func TestSearchControlPonderhit(t *testing.T) {
+	c := newSearchControl(0)
+	c.hold = true
+	c.ponderBudget = time.Hour
+
+	c.ponderhit()
+
+	if c.getDeadline().IsZero() {
+		t.Errorf("ponderhit should set deadline")
+	}
+	if c.expired() {
+		t.Errorf("ponderhit should not expire search")
+	}
+	c.waitRelease() // must not block
+}
+
+func TestSearchStopInfinite(t *testing.T) {
+	game := newGame()
+	game.loadFromString(builtinBoard)
+	game.tt = newTranspositionTable(1)
+
+	control := newSearchControl(0)
+	control.hold = true
+	limits := searchLimits{infinite: true}
+	game.startSearch(control, limits, newTimeManager(limits, colorWhite))
+
+	time.Sleep(50 * time.Millisecond)
+
+	select {
+	case <-control.done:
+		t.Fatalf("infinite search reported bestmove before stop")
+	default:
+	}
+
+	game.stopSearch()
+
+	if game.control != nil {
+		t.Errorf("search control should be released after stop")
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// searchLimits holds the limits sent with UCI 'go'.
+// zero values mean no limit.
+type searchLimits struct {
+	depth       int   // plies
+	nodes       int64 // nodes
+	mate        int   // moves
+	moveTime    time.Duration
+	time        [2]time.Duration // remaining clock for white, black
+	inc         [2]time.Duration // increment per move for white, black
+	movesToGo   int
+	infinite    bool
+	ponder      bool
+	searchMoves []move // restrict root moves
+	multiPV     int    // number of best lines to report, zero means one
+}
+
+// parseGo parses: go [searchmoves m1 m2 ...] [ponder] [wtime N] [btime N] [winc N] [binc N]
+// [movestogo N] [depth N] [nodes N] [mate N] [movetime N] [infinite]
+func parseGo(tokens []string) (searchLimits, error) {
+	var limits searchLimits
+
+	for i := 1; i < len(tokens); i++ {
+		t := tokens[i]
+
+		switch t {
+		case "infinite":
+			limits.infinite = true
+			continue
+		case "ponder":
+			limits.ponder = true
+			continue
+		case "searchmoves":
+			for i+1 < len(tokens) {
+				m, errMove := newMove(tokens[i+1])
+				if errMove != nil {
+					break // not a move: next keyword
+				}
+				limits.searchMoves = append(limits.searchMoves, m)
+				i++
+			}
+			continue
+		}
+
+		if i+1 >= len(tokens) {
+			return limits, fmt.Errorf("go: missing value for '%s'", t)
+		}
+		value := tokens[i+1]
+		i++
+
+		v, errConv := strconv.ParseInt(value, 10, 64)
+		if errConv != nil {
+			return limits, fmt.Errorf("go: bad value for '%s': '%s': %v", t, value, errConv)
+		}
+		ms := time.Duration(v) * time.Millisecond
+
+		switch t {
+		case "wtime":
+			limits.time[colorWhite] = ms
+		case "btime":
+			limits.time[colorBlack] = ms
+		case "winc":
+			limits.inc[colorWhite] = ms
+		case "binc":
+			limits.inc[colorBlack] = ms
+		case "movestogo":
+			limits.movesToGo = int(v)
+		case "depth":
+			limits.depth = int(v)
+		case "nodes":
+			limits.nodes = v
+		case "mate":
+			limits.mate = int(v)
+		case "movetime":
+			limits.moveTime = ms
+		default:
+			return limits, fmt.Errorf("go: unknown parameter: '%s'", t)
+		}
+	}
+
+	return limits, nil
+}
+
+// maxDepth is the deepest iterative deepening iteration allowed.
+func (l searchLimits) maxDepth() int {
+	depth := maxSearchDepth
+	if l.depth > 0 && l.depth < depth {
+		depth = l.depth
+	}
+	if l.mate > 0 && 2*l.mate-1 < depth {
+		depth = 2*l.mate - 1 // mate in N moves takes 2N-1 plies
+	}
+	return depth
+}
+
+// keepSearchMoves compacts the last count moves in the pool so that
+// only moves listed in searchMoves remain. returns new moves count.
+// if no listed move is legal, all moves are kept.
+func keepSearchMoves(moves *movePool, count int, searchMoves []move) int {
+	if len(searchMoves) == 0 {
+		return count
+	}
+	first := len(moves.pool) - count
+	var found bool
+	for _, m := range moves.pool[first:] {
+		if isSearchMove(searchMoves, m) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return count
+	}
+	kept := first
+	for i := first; i < len(moves.pool); i++ {
+		if isSearchMove(searchMoves, moves.pool[i]) {
+			moves.pool[kept] = moves.pool[i]
+			kept++
+		}
+	}
+	moves.drop(len(moves.pool) - kept)
+	return kept - first
+}
+
+// dropExcludedMoves compacts the last count moves in the pool
+// removing moves listed in exclude. returns new moves count.
+func dropExcludedMoves(moves *movePool, count int, exclude []move) int {
+	if len(exclude) == 0 {
+		return count
+	}
+	first := len(moves.pool) - count
+	kept := first
+	for i := first; i < len(moves.pool); i++ {
+		if !isSearchMove(exclude, moves.pool[i]) {
+			moves.pool[kept] = moves.pool[i]
+			kept++
+		}
+	}
+	moves.drop(len(moves.pool) - kept)
+	return kept - first
+}
+
+func isSearchMove(searchMoves []move, m move) bool {
+	for _, s := range searchMoves {
+		if s.equals(m) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseGo(t *testing.T) {
+	limits, err := parseGo(strings.Fields("go searchmoves e2e4 d2d4 wtime 60000 btime 30000 winc 1000 binc 500 movestogo 10 depth 6 nodes 5000 mate 3"))
+	if err != nil {
+		t.Fatalf("parseGo: %v", err)
+	}
+	if len(limits.searchMoves) != 2 || limits.searchMoves[0].String() != "e2e4" || limits.searchMoves[1].String() != "d2d4" {
+		t.Errorf("searchmoves: %v", variation(limits.searchMoves))
+	}
+	if limits.time[colorWhite] != time.Minute || limits.time[colorBlack] != 30*time.Second {
+		t.Errorf("wtime/btime: %v", limits.time)
+	}
+	if limits.inc[colorWhite] != time.Second || limits.inc[colorBlack] != 500*time.Millisecond {
+		t.Errorf("winc/binc: %v", limits.inc)
+	}
+	if limits.movesToGo != 10 || limits.depth != 6 || limits.nodes != 5000 || limits.mate != 3 {
+		t.Errorf("movestogo=%d depth=%d nodes=%d mate=%d", limits.movesToGo, limits.depth, limits.nodes, limits.mate)
+	}
+
+	limits, err = parseGo(strings.Fields("go ponder infinite movetime 100"))
+	if err != nil {
+		t.Fatalf("parseGo: %v", err)
+	}
+	if !limits.ponder || !limits.infinite || limits.moveTime != 100*time.Millisecond {
+		t.Errorf("ponder=%v infinite=%v movetime=%v", limits.ponder, limits.infinite, limits.moveTime)
+	}
+
+	if _, err := parseGo(strings.Fields("go depth")); err == nil {
+		t.Errorf("missing value should fail")
+	}
+	if _, err := parseGo(strings.Fields("go depth x")); err == nil {
+		t.Errorf("bad value should fail")
+	}
+}
+
+func TestSearchLimitsMaxDepth(t *testing.T) {
+	if d := (searchLimits{}).maxDepth(); d != maxSearchDepth {
+		t.Errorf("default max depth: %d", d)
+	}
+	if d := (searchLimits{depth: 4}).maxDepth(); d != 4 {
+		t.Errorf("depth 4: %d", d)
+	}
+	if d := (searchLimits{mate: 2}).maxDepth(); d != 3 {
+		t.Errorf("mate 2: %d", d)
+	}
+}
+
+func TestSearchLimitsSearch(t *testing.T) {
+	game := newGame()
+	game.loadFromString(builtinBoard)
+	game.tt = newTranspositionTable(1)
+	game.dumbBook = false
+
+	m, _ := newMove("a2a3")
+	if best := searchUntil(&game, searchLimits{depth: 3, searchMoves: []move{m}}); best != "a2a3" {
+		t.Errorf("searchmoves a2a3: got %s", best)
+	}
+
+	if best := searchUntil(&game, searchLimits{nodes: 1000}); best == "" {
+		t.Errorf("node limited search found no move")
+	}
+}
+
+func TestExcludeMoves(t *testing.T) {
+	game := newGame()
+	game.loadFromString(builtinBoard)
+	b := game.history[len(game.history)-1]
+
+	pool := defaultMovePool
+	pool.reset()
+	all := b.generateMoves(pool)
+	moves := append([]move(nil), pool.last(all)...)
+
+	// each line must find a move not reported by previous lines
+	var excluded []move
+	for k := 1; k <= 3; k++ {
+		pool.reset()
+		ab := alphaBetaState{moves: pool, excludeMoves: excluded}
+		_, pv, _ := rootAlphaBeta(&ab, b, 2, false)
+		m := pv.best()
+		if m.isNull() || isSearchMove(excluded, m) {
+			t.Fatalf("line %d: move=%s excluded=%v", k, m, variation(excluded))
+		}
+		excluded = append(excluded, m)
+	}
+
+	// no move left
+	pool.reset()
+	ab := alphaBetaState{moves: pool, excludeMoves: moves}
+	if _, pv, _ := rootAlphaBeta(&ab, b, 2, false); len(pv) != 0 {
+		t.Errorf("all %d moves excluded: pv=[%s]", all, pv)
+	}
+}
+
+func TestSearchMultiPV(t *testing.T) {
+	game := newGame()
+	game.loadFromString(builtinBoard)
+	game.tt = newTranspositionTable(1)
+
+	// one single line is reported when there are fewer moves than lines
+	b, err := fenParse(strings.Fields("7k/8/8/8/8/8/6PP/7K w - - 0 1"))
+	if err != nil {
+		t.Fatalf("fen: %v", err)
+	}
+	game.history = []board{b}
+	best := searchUntil(&game, searchLimits{depth: 3, multiPV: maxMultiPV})
+	if errPlay := game.play(best); errPlay != nil {
+		t.Errorf("best move %s: %v", best, errPlay)
+	}
+}
+
+// searchUntil runs searchPerMove without a deadline, stopping on limits only.
+func searchUntil(game *gameState, limits searchLimits) string {
+	tm := newTimeManager(limits, game.history[len(game.history)-1].turn)
+	return game.searchPerMove(newSearchControl(0), limits, tm)
+}
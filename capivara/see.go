@@ -0,0 +1,103 @@
+package main
+
+// static exchange evaluation
+//
+// https://www.chessprogramming.org/Static_Exchange_Evaluation
+//
+// see resolves the sequence of captures on the destination square of a move,
+// each side recapturing with its least valuable attacker and free to stop
+// whenever continuing would lose material (swap algorithm).
+// attackers hidden behind a slider (x-ray) join the exchange as soon as
+// the piece in front of them has captured.
+//
+// pins and promotions by recapturing pawns are ignored.
+
+// seeValue gives piece values in centipawns, indexed by kind.
+// they match the material values used by evaluation.
+var seeValue = [7]int{
+	pieceNone:   0,
+	whiteKing:   10000,
+	whiteQueen:  900,
+	whiteRook:   500,
+	whiteBishop: 300,
+	whiteKnight: 250,
+	whitePawn:   100,
+}
+
+const seeMaxExchange = 32 // at most 32 pieces may capture on one square
+
+// see returns the material balance in centipawns, from the point of view of
+// the side to move, of playing move m on b and resolving all captures on m.dst.
+// quiet moves to a square attacked by the opponent score the loss of the piece.
+func (b *board) see(m move) int {
+	occupied := b.occupied() // pieces are removed as exchange goes on
+
+	attacker := b.square[m.src]
+	victim := b.square[m.dst]
+	if victim == pieceNone && attacker.kind() == whitePawn && m.src%8 != m.dst%8 {
+		// en passant: captured pawn stands beside the attacker
+		passant := m.src - m.src%8 + m.dst%8
+		victim = b.square[passant]
+		occupied &^= bit(passant)
+	}
+
+	var gain [seeMaxExchange]int
+	gain[0] = seeValue[victim.kind()]
+	onTarget := seeValue[attacker.kind()] // value of the piece now standing on the target square
+	if m.promotion != pieceNone {
+		gain[0] += seeValue[m.promotion.kind()] - seeValue[whitePawn]
+		onTarget = seeValue[m.promotion.kind()]
+	}
+	occupied &^= bit(m.src)
+
+	color := colorInverse(attacker.color())
+	attackers := b.attackersTo(m.dst, occupied) & occupied
+	d := 0
+	for d+1 < seeMaxExchange {
+		loc, p := b.seeLeastValuable(attackers & b.bbColor[color])
+		if p == pieceNone {
+			break
+		}
+		if p.kind() == whiteKing && attackers&b.bbColor[colorInverse(color)] != 0 {
+			break // king can not capture into a defended square
+		}
+		d++
+		gain[d] = onTarget - gain[d-1] // speculative: assume this piece will be recaptured
+		onTarget = seeValue[p.kind()]
+		occupied &^= bit(loc)
+		attackers = b.attackersTo(m.dst, occupied) & occupied // reveals x-ray attacker behind it
+		color = colorInverse(color)
+	}
+
+	// negamax the speculative gains backwards: each side may decline to capture
+	for ; d > 0; d-- {
+		gain[d-1] = -max(-gain[d-1], gain[d])
+	}
+
+	return gain[0]
+}
+
+// seeKinds lists piece kinds from least to most valuable.
+var seeKinds = [6]piece{whitePawn, whiteKnight, whiteBishop, whiteRook, whiteQueen, whiteKing}
+
+// seeLeastValuable picks the least valuable piece among attackers.
+// it returns pieceNone when there is none.
+func (b *board) seeLeastValuable(attackers bitboard) (location, piece) {
+	for _, kind := range seeKinds {
+		if set := attackers & b.bbKind[kind]; set != 0 {
+			loc := set.first()
+			return loc, b.square[loc]
+		}
+	}
+	return 0, pieceNone
+}
+
+// seeCapture is a cheaper see for captures: it returns 0 without resolving
+// the exchange when the victim is worth at least as much as the attacker,
+// since such capture can not lose material.
+func (b *board) seeCapture(m move) int {
+	if m.promotion != pieceNone || seeValue[b.square[m.dst].kind()] >= seeValue[b.square[m.src].kind()] {
+		return 0
+	}
+	return b.see(m)
+}
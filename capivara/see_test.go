@@ -0,0 +1,46 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+type seeTest struct {
+	fen      string
+	move     string
+	expected int
+}
+
+var testSeeTable = []seeTest{
+	{"4k3/8/8/4p3/8/8/8/4KQ2 w - - 0 1", "f1f5", 0},                                 // quiet move to safe square
+	{"4k3/8/8/4p3/8/8/8/4QK2 w - - 0 1", "e1e5", 100},                               // free pawn
+	{"4k3/8/3p4/4p3/8/8/8/4QK2 w - - 0 1", "e1e5", -800},                            // defended pawn
+	{"4k3/8/8/3p4/8/2Q5/8/4K3 w - - 0 1", "c3c4", -900},                             // quiet move into pawn attack
+	{"1k1r4/1pp4p/p7/4p3/8/P5P1/1PP4P/2K1R3 w - - 0 1", "e1e5", 100},                // rook takes undefended pawn
+	{"1k1r3q/1ppn3p/p4b2/4p3/8/P2N2P1/1PP1R1BP/2K1Q3 w - - 0 1", "d3e5", 100 - 250}, // knight lost for pawn
+	{"4r1k1/8/8/4p3/8/8/4R3/4R1K1 w - - 0 1", "e2e5", 100},                          // x-ray: doubled rooks win pawn
+	{"4r1k1/4r3/8/4p3/8/8/4R3/4R1K1 w - - 0 1", "e2e5", 100 - 500},                  // x-ray on both sides
+	{"3rk3/8/8/8/8/8/3p4/3RK3 w - - 0 1", "d1d2", 100},                              // king recaptures last
+	{"3rk3/8/8/8/8/1n6/3p4/3RK3 w - - 0 1", "d1d2", 100 - 500},                      // king can not recapture defended piece
+	{"4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1", "e5d6", 100},                              // en passant
+	{"1r2k3/P7/8/8/8/8/8/4K3 w - - 0 1", "a7b8q", 500 + 900 - 100},                  // capture with promotion
+	{"4k3/8/8/8/8/8/3q4/4K3 w - - 0 1", "e1d2", 900},                                // king takes undefended queen
+	{"rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq d6 0 2", "e4d5", 0},    // pawn trade
+	{"rnbqkb1r/ppp1pppp/5n2/3p4/4P3/2N5/PPPP1PPP/R1BQKBNR w KQkq - 2 3", "e4d5", 0}, // knights and pawns traded
+}
+
+func TestSee(t *testing.T) {
+	for _, data := range testSeeTable {
+		b, err := fenParse(strings.Fields(data.fen))
+		if err != nil {
+			t.Fatalf("fen %s: %v", data.fen, err)
+		}
+		m, errMove := newMove(data.move)
+		if errMove != nil {
+			t.Fatalf("move %s: %v", data.move, errMove)
+		}
+		if s := b.see(m); s != data.expected {
+			t.Errorf("see %s %s: got %d expected %d", data.fen, data.move, s, data.expected)
+		}
+	}
+}
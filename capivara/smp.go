@@ -0,0 +1,64 @@
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// lazy SMP
+//
+// https://www.chessprogramming.org/Lazy_SMP
+//
+// helper threads run their own iterative deepening on the same root,
+// sharing only the transposition table. they never report moves:
+// their sole purpose is to fill the table with results the main
+// thread can pick up. odd helpers start one ply deeper, so that
+// threads do not keep searching the very same tree in lockstep.
+//
+// helpers stop when the main thread finishes its search.
+// node limits from 'go nodes' are enforced on the main thread only.
+
+type smpHelpers struct {
+	control *searchControl // stopped by main thread, expires with search control
+	nodes   atomic.Int64   // nodes searched by all helpers
+	wg      sync.WaitGroup
+}
+
+// startHelpers launches game.threads-1 helper threads.
+func (game *gameState) startHelpers(control *searchControl, b board, limits searchLimits) *smpHelpers {
+	h := &smpHelpers{control: newSearchControl(0)}
+	h.control.parent = control
+
+	for id := 1; id < game.threads; id++ {
+		h.wg.Add(1)
+		go func() {
+			defer h.wg.Done()
+			game.helperSearch(h, id, b, limits)
+		}()
+	}
+
+	return h
+}
+
+func (game *gameState) helperSearch(h *smpHelpers, id int, b board, limits searchLimits) {
+	moves := newMovePool()
+	order := newMoveOrdering()
+	path := game.historyPath()
+
+	for depth := 1 + id%2; depth <= limits.maxDepth(); depth++ {
+		moves.reset()
+		ab := alphaBetaState{control: h.control, moves: moves, tt: game.tt, path: path,
+			searchMoves: limits.searchMoves, order: order, prune: game.prune}
+		rootAlphaBeta(&ab, b, depth, game.addChildren)
+		h.nodes.Add(ab.nodes)
+		if ab.cancelled || ab.singleChildren {
+			return
+		}
+	}
+}
+
+// stop interrupts helpers and waits for them to exit.
+func (h *smpHelpers) stop() {
+	h.control.stop()
+	h.wg.Wait()
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestLazySMP(t *testing.T) {
+	game := newGame()
+	game.loadFromString(builtinBoard)
+	game.tt = newTranspositionTable(1)
+	game.threads = 4
+
+	best := searchUntil(&game, searchLimits{depth: 5})
+	if errPlay := game.play(best); errPlay != nil {
+		t.Errorf("best move %s: %v", best, errPlay)
+	}
+}
+
+// TestTranspositionTableConcurrent: concurrent writers to the same slot
+// must never produce an entry mixing two stores.
+func TestTranspositionTableConcurrent(t *testing.T) {
+	tt := newTranspositionTable(1)
+	key := uint64(0x0123456789abcdef)
+
+	var wg sync.WaitGroup
+	for w := 1; w <= 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m := move{src: location(w), dst: location(w + 8)}
+			for i := 0; i < 10000; i++ {
+				tt.store(key, w, ttExact, w*100, m)
+				if e, found := tt.probe(key); found {
+					if int(e.score) != int(e.depth)*100 || int(e.best.src) != int(e.depth) {
+						t.Errorf("torn entry: %+v", e)
+						return
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
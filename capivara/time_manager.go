@@ -0,0 +1,144 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// time management
+//
+// https://www.chessprogramming.org/Time_Management
+//
+// hard is the deadline enforced inside the search: an iteration
+// running past it is cancelled and its result discarded.
+//
+// soft is checked between iterations only. it is stretched while
+// the best move keeps changing and shrunk while the best move is
+// stable, so that easy moves save clock for difficult ones.
+//
+// a forced move stops the search early too: with a single legal move
+// the root returns at once, and once part of the soft limit is spent
+// a reduced search verifies whether every alternative falls behind
+// the best move by forcedMargin.
+
+const (
+	defaultAvailTime  = 30 * time.Second // when go has no time limit at all
+	defaultMovesToGo  = 20               // share of remaining time when movestogo is missing
+	timeSafetyMargin  = 50 * time.Millisecond
+	minimumMoveBudget = 10 * time.Millisecond
+
+	hardSoftRatio = 3    // hard limit as multiple of soft limit
+	unstableScale = 1.5  // soft limit stretch when best move changes
+	maxScale      = 2.0  // soft limit never stretched beyond this
+	stableScale   = 0.85 // soft limit shrink per iteration with same best move
+	minScale      = 0.5  // soft limit never shrunk below this
+
+	forcedMargin   = 200  // centipawns every alternative must lose for a forced move
+	forcedMinDepth = 5    // iteration depth before testing for a forced move
+	forcedShare    = 0.25 // share of soft limit spent before testing for a forced move
+)
+
+type timeManager struct {
+	soft time.Duration // zero means no early stop
+	hard time.Duration // zero means no deadline
+
+	scale    float64
+	lastBest move
+}
+
+func newTimeManager(limits searchLimits, turn pieceColor) *timeManager {
+	tm := &timeManager{scale: 1}
+
+	if limits.moveTime > 0 {
+		tm.hard = limits.moveTime // use exactly what was asked for
+		return tm
+	}
+
+	remain := limits.time[turn]
+	if remain <= 0 {
+		if limits.depth > 0 || limits.nodes > 0 || limits.mate > 0 {
+			return tm // stop on depth, nodes or mate limits only
+		}
+		tm.hard = defaultAvailTime / defaultMovesToGo // just a default
+		return tm
+	}
+
+	remain -= timeSafetyMargin
+	if remain < minimumMoveBudget {
+		tm.soft = minimumMoveBudget
+		tm.hard = minimumMoveBudget
+		return tm
+	}
+
+	movesToGo := limits.movesToGo
+	if movesToGo < 1 {
+		movesToGo = defaultMovesToGo
+	}
+
+	tm.soft = remain/time.Duration(movesToGo) + limits.inc[turn]*3/4
+
+	// never spend on a single move the time required by the next ones
+	spread := movesToGo
+	if spread > 4 {
+		spread = 4
+	}
+	tm.hard = min(hardSoftRatio*tm.soft, remain*4/5/time.Duration(spread))
+
+	tm.soft = max(min(tm.soft, tm.hard), minimumMoveBudget)
+	tm.hard = max(tm.hard, minimumMoveBudget)
+
+	return tm
+}
+
+// update is called after each completed iteration.
+func (tm *timeManager) update(depth int, best move) {
+	if depth > 1 {
+		if best.equals(tm.lastBest) {
+			tm.scale = max(tm.scale*stableScale, minScale)
+		} else {
+			tm.scale = min(tm.scale*unstableScale, maxScale)
+		}
+	}
+	tm.lastBest = best
+}
+
+// stopEarly reports whether the next iteration should not be started.
+// elapsed time is measured from the point the deadline was armed,
+// thus pondering time is not charged until ponderhit.
+func (tm *timeManager) stopEarly(control *searchControl) bool {
+	if tm.soft == 0 {
+		return false
+	}
+	start := control.startTime()
+	if start.IsZero() {
+		return false // pondering or infinite
+	}
+	return time.Since(start) > time.Duration(float64(tm.soft)*tm.scale)
+}
+
+// checkForced reports whether to test for a forced move after iteration depth.
+func (tm *timeManager) checkForced(control *searchControl, depth int) bool {
+	if tm.soft == 0 || depth < forcedMinDepth {
+		return false
+	}
+	start := control.startTime()
+	if start.IsZero() {
+		return false // pondering or infinite
+	}
+	return time.Since(start) > time.Duration(float64(tm.soft)*tm.scale*forcedShare)
+}
+
+// forcedMove reports whether every root move excluded from ab scores at
+// least forcedMargin below score, searching at half depth with a null window.
+func forcedMove(ab *alphaBetaState, b board, depth, score int, addChildren bool) bool {
+	if isMateScore(score) {
+		return false // mate scores are handled by the caller
+	}
+	beta := score - forcedMargin
+	alternative, _, _ := rootAlphaBetaWindow(ab, b, max(depth/2, 1), beta-pvsWindow, beta, addChildren)
+	return !ab.cancelled && alternative < beta
+}
+
+func (tm *timeManager) String() string {
+	return fmt.Sprintf("soft=%v hard=%v", tm.soft, tm.hard)
+}
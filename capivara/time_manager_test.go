@@ -0,0 +1,138 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeManagerLimits(t *testing.T) {
+	tm := newTimeManager(searchLimits{moveTime: time.Second}, colorWhite)
+	if tm.hard != time.Second || tm.soft != 0 {
+		t.Errorf("movetime: %v", tm)
+	}
+
+	limits := searchLimits{movesToGo: 10}
+	limits.time[colorWhite] = 10*time.Second + timeSafetyMargin
+	limits.inc[colorWhite] = 400 * time.Millisecond
+	tm = newTimeManager(limits, colorWhite)
+	if tm.soft != 1300*time.Millisecond || tm.hard != 2*time.Second {
+		t.Errorf("clock: %v", tm)
+	}
+
+	limits = searchLimits{movesToGo: 1}
+	limits.time[colorBlack] = time.Second
+	tm = newTimeManager(limits, colorBlack)
+	if tm.hard >= time.Second || tm.soft > tm.hard {
+		t.Errorf("limits must stay below remaining clock: %v", tm)
+	}
+
+	limits = searchLimits{}
+	limits.time[colorWhite] = time.Minute // bullet
+	tm = newTimeManager(limits, colorWhite)
+	if tm.hard > 15*time.Second || tm.soft > 5*time.Second {
+		t.Errorf("bullet: %v", tm)
+	}
+
+	if tm := newTimeManager(searchLimits{depth: 3}, colorWhite); tm.hard != 0 {
+		t.Errorf("depth-only search must have no deadline: %v", tm)
+	}
+	if tm := newTimeManager(searchLimits{}, colorWhite); tm.hard <= 0 {
+		t.Errorf("search without limits needs a default deadline: %v", tm)
+	}
+}
+
+func TestTimeManagerScale(t *testing.T) {
+	limits := searchLimits{}
+	limits.time[colorWhite] = time.Minute
+	tm := newTimeManager(limits, colorWhite)
+
+	e2e4, _ := newMove("e2e4")
+	d2d4, _ := newMove("d2d4")
+
+	tm.update(1, e2e4)
+	tm.update(2, d2d4) // unstable
+	if tm.scale <= 1 {
+		t.Errorf("best move change should stretch soft limit: scale=%v", tm.scale)
+	}
+	for depth := 3; depth < 20; depth++ {
+		tm.update(depth, d2d4) // stable
+	}
+	if tm.scale != minScale {
+		t.Errorf("stable best move should shrink soft limit: scale=%v", tm.scale)
+	}
+}
+
+func TestTimeManagerStopEarly(t *testing.T) {
+	tm := &timeManager{soft: 10 * time.Millisecond, hard: time.Hour, scale: 1}
+
+	ponder := newSearchControl(0)
+	time.Sleep(20 * time.Millisecond)
+	if tm.stopEarly(ponder) {
+		t.Errorf("must not stop early before deadline is armed")
+	}
+
+	control := newSearchControl(tm.hard)
+	if tm.stopEarly(control) {
+		t.Errorf("must not stop early before soft limit")
+	}
+	time.Sleep(20 * time.Millisecond)
+	if !tm.stopEarly(control) {
+		t.Errorf("must stop early after soft limit")
+	}
+
+	// pondering time is not charged: clock starts on ponderhit
+	ponder.ponderBudget = tm.hard
+	ponder.ponderhit()
+	if tm.stopEarly(ponder) {
+		t.Errorf("must not stop early right after ponderhit")
+	}
+	time.Sleep(20 * time.Millisecond)
+	if !tm.stopEarly(ponder) {
+		t.Errorf("must stop early after soft limit since ponderhit")
+	}
+}
+
+func TestForcedMove(t *testing.T) {
+	table := []struct {
+		fen    string
+		forced bool
+	}{
+		{"4k3/8/8/3q4/8/8/3R4/4K3 w - - 0 1", true}, // only Rxd5 saves the rook and wins the queen
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", false},
+	}
+	for _, data := range table {
+		b, err := fenParse(strings.Fields(data.fen))
+		if err != nil {
+			t.Fatalf("%s: fen: %v", data.fen, err)
+		}
+		depth := 4
+		moves := newMovePool()
+		ab := alphaBetaState{moves: moves}
+		score, pv, _ := rootAlphaBeta(&ab, b, depth, false)
+
+		moves.reset()
+		verify := alphaBetaState{moves: moves, excludeMoves: []move{pv.best()}}
+		if forced := forcedMove(&verify, b, depth, score, false); forced != data.forced {
+			t.Errorf("%s: best=%s score=%d forced=%v expected=%v", data.fen, pv.best(), score, forced, data.forced)
+		}
+	}
+}
+
+func TestTimeManagerCheckForced(t *testing.T) {
+	tm := &timeManager{soft: 40 * time.Millisecond, hard: time.Hour, scale: 1}
+	control := newSearchControl(tm.hard)
+	if tm.checkForced(control, forcedMinDepth) {
+		t.Errorf("must not test for forced move before spending part of soft limit")
+	}
+	time.Sleep(20 * time.Millisecond)
+	if tm.checkForced(control, forcedMinDepth-1) {
+		t.Errorf("must not test for forced move at shallow depth")
+	}
+	if !tm.checkForced(control, forcedMinDepth) {
+		t.Errorf("must test for forced move after spending part of soft limit")
+	}
+	if tm.checkForced(newSearchControl(0), forcedMinDepth) {
+		t.Errorf("must not test for forced move without deadline")
+	}
+}
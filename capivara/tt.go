@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"sync/atomic"
+	"unsafe"
+)
+
+// transposition table
+//
+// https://www.chessprogramming.org/Transposition_Table
+//
+// fixed size table indexed by the low bits of the zobrist key,
+// shared by all search threads.
+// on collision the new entry always replaces the old one,
+// except that a shallower search for the same position never
+// replaces a deeper one.
+
+type ttBound uint8
+
+const (
+	ttNone  ttBound = iota
+	ttExact         // score is exact
+	ttLower         // score is a lower bound (failed high)
+	ttUpper         // score is an upper bound (failed low)
+)
+
+const defaultHashMB = 16
+
+// ttEntry is the decoded content of a table slot.
+type ttEntry struct {
+	key   uint64
+	score int32 // mate scores are relative to this node, see scoreToTT
+	best  move
+	depth int8
+	bound ttBound
+}
+
+// ttSlot is shared by all search threads without locking.
+//
+// https://www.chessprogramming.org/Shared_Hash_Table#Lockless
+//
+// the entry is packed into data and check holds key^data.
+// a slot torn by concurrent writers fails the key check
+// and is simply treated as a miss.
+type ttSlot struct {
+	check atomic.Uint64
+	data  atomic.Uint64
+}
+
+func (e ttEntry) pack() uint64 {
+	return uint64(uint16(int16(e.score))) |
+		uint64(e.best.src&63)<<16 |
+		uint64(e.best.dst&63)<<22 |
+		uint64(e.best.promotion&15)<<28 |
+		uint64(uint8(e.depth))<<32 |
+		uint64(e.bound)<<40
+}
+
+func unpackEntry(key, data uint64) ttEntry {
+	return ttEntry{
+		key:   key,
+		score: int32(int16(uint16(data))),
+		best: move{
+			src:       location(data >> 16 & 63),
+			dst:       location(data >> 22 & 63),
+			promotion: piece(data >> 28 & 15),
+		},
+		depth: int8(uint8(data >> 32)),
+		bound: ttBound(data >> 40 & 3),
+	}
+}
+
+type transpositionTable struct {
+	slots []ttSlot
+	mask  uint64
+	used  atomic.Int64 // occupied slots
+}
+
+func newTranspositionTable(sizeMB int) *transpositionTable {
+	if sizeMB < 1 {
+		sizeMB = 1
+	}
+	size := tableSlots(sizeMB, unsafe.Sizeof(ttSlot{}))
+
+	return &transpositionTable{
+		slots: make([]ttSlot, size),
+		mask:  size - 1,
+	}
+}
+
+// tableSlots returns how many slots of slotSize bytes fit into sizeMB,
+// rounded down to power of two so that keys are mapped by mask.
+func tableSlots(sizeMB int, slotSize uintptr) uint64 {
+	maxSlots := uint64(max(sizeMB, 1)) * 1024 * 1024 / uint64(slotSize)
+	size := uint64(1)
+	for size*2 <= maxSlots {
+		size *= 2
+	}
+	return size
+}
+
+// clear must not run concurrently with search.
+func (tt *transpositionTable) clear() {
+	for i := range tt.slots {
+		tt.slots[i].check.Store(0)
+		tt.slots[i].data.Store(0)
+	}
+	tt.used.Store(0)
+}
+
+func (tt *transpositionTable) sizeMB() int {
+	return len(tt.slots) * int(unsafe.Sizeof(ttSlot{})) / (1024 * 1024)
+}
+
+func (tt *transpositionTable) probe(key uint64) (ttEntry, bool) {
+	slot := &tt.slots[key&tt.mask]
+	data := slot.data.Load()
+	if slot.check.Load()^data != key {
+		return ttEntry{}, false
+	}
+	e := unpackEntry(key, data)
+	return e, e.bound != ttNone
+}
+
+func (tt *transpositionTable) store(key uint64, depth int, bound ttBound, score int, best move) {
+	slot := &tt.slots[key&tt.mask]
+	old := slot.data.Load()
+	if slot.check.Load()^old == key && int(unpackEntry(key, old).depth) > depth {
+		return // keep deeper result for same position
+	}
+	if old == 0 {
+		tt.used.Add(1)
+	}
+	data := ttEntry{score: int32(score), best: best, depth: int8(depth), bound: bound}.pack()
+	slot.data.Store(data)
+	slot.check.Store(key ^ data)
+}
+
+// usage returns table occupation in permill
+func (tt *transpositionTable) usage() int {
+	return int(tt.used.Load() * 1000 / int64(len(tt.slots)))
+}
+
+func (tt *transpositionTable) String() string {
+	return fmt.Sprintf("size=%dMB entries=%d usage=%d/1000", tt.sizeMB(), len(tt.slots), tt.usage())
+}
+
+// ttCutoff checks whether a table entry can answer the search for window alpha..beta at ply.
+func ttCutoff(e ttEntry, alpha, beta, depth, ply int) (int, bool) {
+	if int(e.depth) < depth {
+		return 0, false
+	}
+	score := scoreFromTT(int(e.score), ply)
+	switch e.bound {
+	case ttExact:
+		return score, true
+	case ttLower:
+		if score >= beta {
+			return beta, true
+		}
+	case ttUpper:
+		if score <= alpha {
+			return alpha, true
+		}
+	}
+	return 0, false
+}
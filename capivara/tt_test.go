@@ -0,0 +1,102 @@
+package main
+
+import "testing"
+
+func TestTranspositionTableStore(t *testing.T) {
+	tt := newTranspositionTable(1)
+
+	if n := len(tt.slots); n&(n-1) != 0 {
+		t.Errorf("entries=%d not power of two", n)
+	}
+
+	key := uint64(0x1234567890abcdef)
+	m := move{src: 12, dst: 28}
+
+	if _, found := tt.probe(key); found {
+		t.Errorf("unexpected entry in empty table")
+	}
+
+	tt.store(key, 3, ttLower, 150, m)
+
+	e, found := tt.probe(key)
+	if !found {
+		t.Fatalf("missing entry")
+	}
+	if e.depth != 3 || e.bound != ttLower || e.score != 150 || e.best != m {
+		t.Errorf("bad entry: %+v", e)
+	}
+
+	if _, cutoff := ttCutoff(e, 0, 200, 3, 0); cutoff {
+		t.Errorf("lower bound 150 should not cut beta=200")
+	}
+	if score, cutoff := ttCutoff(e, 0, 100, 3, 0); !cutoff || score != 100 {
+		t.Errorf("lower bound 150 should cut beta=100: cutoff=%v score=%v", cutoff, score)
+	}
+	if _, cutoff := ttCutoff(e, 0, 100, 4, 0); cutoff {
+		t.Errorf("shallower entry should not cut")
+	}
+
+	// shallower search must not replace deeper one
+	tt.store(key, 2, ttExact, 0, nullMove)
+	if e, _ := tt.probe(key); e.depth != 3 {
+		t.Errorf("deeper entry replaced: %+v", e)
+	}
+
+	tt.clear()
+	if _, found := tt.probe(key); found {
+		t.Errorf("entry survived clear")
+	}
+}
+
+func TestTranspositionTableSearch(t *testing.T) {
+	for _, brd := range []string{b7, b9, b11, castling} {
+		game := newGame()
+		game.loadFromString(brd)
+		b := game.history[len(game.history)-1]
+
+		depth := 4
+
+		moves := defaultMovePool
+		moves.reset()
+		plain := alphaBetaState{moves: moves}
+		scorePlain, _, _ := rootAlphaBeta(&plain, b, depth, false)
+
+		// iterative deepening sharing the table
+		tt := newTranspositionTable(1)
+		var nodes int64
+		var scoreTT int
+		for d := 1; d <= depth; d++ {
+			moves.reset()
+			ab := alphaBetaState{moves: moves, tt: tt}
+			scoreTT, _, _ = rootAlphaBeta(&ab, b, d, false)
+			nodes += ab.nodes
+		}
+
+		if scoreTT != scorePlain {
+			t.Errorf("score with tt=%v without tt=%v", scoreTT, scorePlain)
+		}
+		t.Logf("depth=%d nodes: plain=%d iterative+tt=%d", depth, plain.nodes, nodes)
+	}
+}
+
+// TestTranspositionTableMate: mate scores are stored relative to the node
+// and must come back relative to the root, whatever the ply.
+func TestTranspositionTableMate(t *testing.T) {
+	tt := newTranspositionTable(1)
+	key := uint64(0xfedcba0987654321)
+
+	// found at ply 3: side to move at root mates at ply 7, i.e. 4 plies below node
+	tt.store(key, 5, ttExact, scoreToTT(mateIn(7), 3), nullMove)
+	e, _ := tt.probe(key)
+
+	// same position reached at ply 5: mate is now at ply 9
+	if score, cutoff := ttCutoff(e, alphabetaMin, alphabetaMax, 5, 5); !cutoff || score != mateIn(9) {
+		t.Errorf("mate score: cutoff=%v score=%d expected=%d", cutoff, score, mateIn(9))
+	}
+
+	tt.store(key, 6, ttExact, scoreToTT(matedIn(4), 2), nullMove)
+	e, _ = tt.probe(key)
+	if score, _ := ttCutoff(e, alphabetaMin, alphabetaMax, 5, 1); score != matedIn(3) {
+		t.Errorf("mated score: score=%d expected=%d", score, matedIn(3))
+	}
+}
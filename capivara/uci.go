@@ -3,41 +3,93 @@ package main
 import (
 	"fmt"
 	"os"
-	"strconv"
+	"strings"
 	"time"
 )
 
 type uciCommand struct {
-	name string
-	call func(game *gameState, tokens []string)
+	name         string
+	call         func(game *gameState, tokens []string)
+	duringSearch bool // command may run while search goroutine is active
 }
 
 var tableUci = []uciCommand{
-	{"uci", uciCmdUci},
-	{"isready", uciCmdIsReady},
-	{"position", uciCmdPosition},
-	{"quit", uciCmdQuit},
-	{"go", uciCmdGo},
+	{"uci", uciCmdUci, false},
+	{"isready", uciCmdIsReady, true},
+	{"position", uciCmdPosition, false},
+	{"quit", uciCmdQuit, true},
+	{"go", uciCmdGo, false},
+	{"setoption", uciCmdSetOption, false},
+	{"stop", uciCmdStop, true},
+	{"ponderhit", uciCmdPonderHit, true},
+	{"ucinewgame", uciCmdNewGame, false},
 }
 
-func uciCmdUci(_ *gameState, _ []string) {
+func uciCmdUci(game *gameState, _ []string) {
 	fmt.Println("id name Capivara", fullVersion())
 	fmt.Println("id author https://github.com/udhos/capivara")
+	for _, o := range tableOption {
+		fmt.Println(o.withGameDefault(game))
+	}
 	fmt.Println("uciok")
 }
 
+func uciCmdSetOption(game *gameState, tokens []string) {
+
+	// setoption name Hash value 32
+
+	name, value := parseSetOption(tokens)
+
+	if errOption := game.setOption(name, value); errOption != nil {
+		game.println(fmt.Sprintf("setoption: %v", errOption))
+	}
+}
+
+// parseSetOption extracts name and value from:
+// setoption name <id> [value <x>]
+// both name and value may contain spaces.
+func parseSetOption(tokens []string) (string, string) {
+	var name, value []string
+	var dst *[]string
+	for _, t := range tokens[1:] {
+		switch {
+		case t == "name" && dst == nil:
+			dst = &name
+		case t == "value" && dst == &name:
+			dst = &value
+		case dst != nil:
+			*dst = append(*dst, t)
+		}
+	}
+	return strings.Join(name, " "), strings.Join(value, " ")
+}
+
+func uciCmdNewGame(game *gameState, _ []string) {
+	game.startNewGame()
+}
+
 func uciCmdIsReady(_ *gameState, _ []string) {
 	fmt.Println("readyok")
 }
 
 func uciCmdQuit(game *gameState, _ []string) {
+	game.stopSearch()
 	game.println("good bye")
 	os.Exit(0)
 }
 
 func uciCmdPosition(game *gameState, tokens []string) {
+	game.badPosition = uciLoadPosition(game, tokens)
+	if game.badPosition != nil {
+		game.println(fmt.Sprintf("position: %v", game.badPosition))
+	}
+}
+
+// uciLoadPosition replaces the board as requested by the position command.
+// on error the board left behind is not the position the GUI asked for.
+func uciLoadPosition(game *gameState, tokens []string) error {
 	if len(tokens) < 2 {
-		return
+		return fmt.Errorf("missing position")
 	}
 
 	var moves []string
@@ -49,10 +101,10 @@ func uciCmdPosition(game *gameState, tokens []string) {
 		game.loadFromString(builtinBoard) // reset board
 
 		if len(tokens) < 3 {
-			return
+			return nil
 		}
 		if tokens[2] != "moves" {
-			return
+			return nil
 		}
 		moves = tokens[3:]
 
@@ -73,10 +125,12 @@ func uciCmdPosition(game *gameState, tokens []string) {
 
 		game.println(fmt.Sprintf("position fen: %v", fen))
 
-		game.loadFromFen(fen)
+		if errFen := game.loadFromFen(fen); errFen != nil {
+			return errFen
+		}
 
 	default:
-		return
+		return fmt.Errorf("unknown position: %s", tokens[1])
 	}
 
 	game.println(fmt.Sprintf("position moves: %v", moves))
@@ -84,72 +138,159 @@ func uciCmdPosition(game *gameState, tokens []string) {
 	// play every move
 	for _, m := range moves {
 		if errPlay := game.play(m); errPlay != nil {
-			game.println(fmt.Sprintf("play error: %v", errPlay))
-			return
+			return fmt.Errorf("play error: %v", errPlay)
 		}
 	}
 
 	game.println(fmt.Sprintf("played: %v", moves))
+
+	return nil
+}
+
+func uciCmdStop(game *gameState, _ []string) {
+	game.stopSearch()
+}
+
+func uciCmdPonderHit(game *gameState, _ []string) {
+	if game.control != nil {
+		game.control.ponderhit()
+	}
 }
 
 func uciCmdGo(game *gameState, tokens []string) {
 
 	// go wtime 300000 btime 300000 winc 0 binc 0
+	// go infinite
+	// go ponder wtime 300000 btime 300000
+	// go depth 8
+	// go nodes 100000 searchmoves e2e4 d2d4
 
 	game.println(fmt.Sprintf("version %s", shortVersion()))
 
 	game.println(fmt.Sprintf("go: %v", tokens))
 
-	avail := 30 * time.Second // just a default
+	if game.badPosition != nil {
+		// searching the board left behind would report a move the GUI rejects
+		fmt.Printf("info string position: %v\n", game.badPosition)
+		fmt.Println("bestmove 0000")
+		return
+	}
+
+	limits, errParse := parseGo(tokens)
+	if errParse != nil {
+		// keep searching with what was understood: GUI expects bestmove
+		game.println(fmt.Sprintf("error: %v", errParse))
+	}
+	limits.multiPV = game.multiPV
 
 	turn := game.history[len(game.history)-1].turn
 
-	game.println(fmt.Sprintf("turn: %s", turn.name()))
+	tm := newTimeManager(limits, turn)
 
-	var timeLabel string
-	if turn == colorWhite {
-		timeLabel = "wtime"
-	} else {
-		timeLabel = "btime"
-	}
+	game.println(fmt.Sprintf("turn: %s time: %v depth=%d nodes=%d mate=%d infinite=%v ponder=%v searchmoves=%v",
+		turn.name(), tm, limits.depth, limits.nodes, limits.mate, limits.infinite, limits.ponder, variation(limits.searchMoves)))
+
+	var control *searchControl
 
-	var perMove bool
+	switch {
+	case limits.infinite:
+		control = newSearchControl(0)
+		control.hold = true
+	case limits.ponder:
+		control = newSearchControl(0)
+		control.hold = true
+		control.ponderBudget = tm.hard
+	default:
+		control = newSearchControl(tm.hard)
+	}
 
-	for i := 1; i < len(tokens)-1; i++ {
-		t := tokens[i]
+	game.startSearch(control, limits, tm)
+}
 
-		if t == "movetime" {
-			// found per-move time
-			avail = parseTime(game, tokens[i+1])
-			perMove = true
-			break
+// startSearch runs the search in its own goroutine, so that the
+// input loop keeps serving isready, stop, ponderhit and quit.
+func (game *gameState) startSearch(control *searchControl, limits searchLimits, tm *timeManager) {
+	game.control = control
+	go func() {
+		defer close(control.done)
+		var bestMove string
+		if limits.mate > 0 {
+			bestMove = game.solveMate(control, limits.mate)
 		}
-
-		if t == timeLabel {
-			// found remaining time
-			avail = parseTime(game, tokens[i+1])
-			break
+		if bestMove == "" {
+			bestMove = game.searchPerMove(control, limits, tm) // no mate: play on
 		}
+		control.waitRelease()
+		fmt.Println("bestmove", bestMove)
+	}()
+}
+
+// waitSearch waits for the search goroutine, if any, to report bestmove.
+func (game *gameState) waitSearch() {
+	if game.control == nil {
+		return
 	}
+	<-game.control.done
+	game.control = nil
+}
 
-	game.println(fmt.Sprintf("available time: %v", avail))
+func (game *gameState) stopSearch() {
+	if game.control == nil {
+		return
+	}
+	game.control.stop()
+	game.waitSearch()
+}
 
-	var bestMove string
+// finishSearch lets a timed search complete, but interrupts
+// infinite and ponder searches that would otherwise never end.
+func (game *gameState) finishSearch() {
+	if game.control == nil {
+		return
+	}
+	if game.control.hold {
+		game.control.stop()
+	}
+	game.waitSearch()
+}
 
-	if perMove {
-		bestMove = game.searchPerMove(avail, avail)
-	} else {
-		bestMove = game.search(avail)
+// uciScore converts search score into UCI score: centipawns or mate in moves.
+func uciScore(score int) string {
+	if isMateScore(score) {
+		return fmt.Sprintf("mate %d", mateMoves(score))
 	}
+	return fmt.Sprintf("cp %d", score)
+}
 
-	fmt.Println("bestmove", bestMove)
+// uciInfo formats search progress after one iterative deepening iteration.
+// multipv is the line index, zero when MultiPV is off.
+func uciInfo(depth, seldepth, multipv int, score int, nodes int64, elapsed time.Duration, pv []move) string {
+	if seldepth < depth {
+		seldepth = depth
+	}
+	ms := elapsed.Milliseconds()
+	nps := nodes * 1000 / max(ms, 1) // first iterations may finish within a millisecond
+	info := fmt.Sprintf("info depth %d seldepth %d", depth, seldepth)
+	if multipv > 0 {
+		info += fmt.Sprintf(" multipv %d", multipv)
+	}
+	info += fmt.Sprintf(" score %s nodes %d nps %d time %d", uciScore(score), nodes, nps, ms)
+	if len(pv) > 0 && !pv[0].isNull() {
+		info += " pv"
+		for _, m := range pv {
+			info += " " + m.String()
+		}
+	}
+	return info
 }
 
-func parseTime(game *gameState, t string) time.Duration {
-	v, errConv := strconv.Atoi(t)
-	if errConv != nil {
-		game.println(fmt.Sprintf("error: %s: %v", t, errConv))
+// uciCurrMove reports root moves, but only after the first second,
+// in order not to flood the GUI during short searches.
+func uciCurrMove(begin time.Time, depth int) func(m move, number int) {
+	return func(m move, number int) {
+		if time.Since(begin) < time.Second {
+			return
+		}
+		fmt.Printf("info depth %d currmove %s currmovenumber %d\n", depth, m, number)
 	}
-	avail := time.Duration(v) * time.Millisecond
-	return avail
 }
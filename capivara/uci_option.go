@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// uci options
+//
+// every option in tableOption is advertised in response to 'uci'
+// and can be changed with 'setoption name <id> [value <x>]'.
+
+type optionType string
+
+const (
+	optionSpin   optionType = "spin"
+	optionCheck  optionType = "check"
+	optionCombo  optionType = "combo"
+	optionString optionType = "string"
+	optionButton optionType = "button"
+)
+
+// optionValue carries the parsed value according to option type.
+type optionValue struct {
+	str   string // string, combo
+	spin  int    // spin
+	check bool   // check
+}
+
+type uciOption struct {
+	name         string
+	kind         optionType
+	defaultValue string
+	min, max     int      // spin
+	vars         []string // combo
+	set          func(game *gameState, v optionValue)
+	current      func(game *gameState) optionValue // value set from command line, advertised as default
+}
+
+const (
+	defaultThreads = 1
+	maxThreads     = 256
+	defaultMultiPV = 1
+	maxMultiPV     = 64
+)
+
+var tableOption = []uciOption{
+	{name: "Hash", kind: optionSpin, defaultValue: strconv.Itoa(defaultHashMB), min: 1, max: 4096, set: optionHash, current: currentHash},
+	{name: "Clear Hash", kind: optionButton, set: optionClearHash},
+	{name: "Threads", kind: optionSpin, defaultValue: strconv.Itoa(defaultThreads), min: 1, max: maxThreads, set: optionThreads, current: currentThreads},
+	{name: "OwnBook", kind: optionCheck, defaultValue: "true", set: optionOwnBook, current: currentOwnBook},
+	{name: "BookFile", kind: optionString, defaultValue: "<empty>", set: optionBookFile},
+	{name: "AddChildren", kind: optionCheck, defaultValue: "false", set: optionAddChildren, current: currentAddChildren},
+	{name: "MultiPV", kind: optionSpin, defaultValue: strconv.Itoa(defaultMultiPV), min: 1, max: maxMultiPV, set: optionMultiPV},
+	{name: "BookSeed", kind: optionSpin, defaultValue: "0", min: 0, max: math.MaxInt32, set: optionBookSeed},
+	{name: "NullMove", kind: optionCheck, defaultValue: "true", set: optionNullMove, current: currentNullMove},
+	{name: "LMR", kind: optionCheck, defaultValue: "true", set: optionLMR, current: currentLMR},
+	{name: "Futility", kind: optionCheck, defaultValue: "true", set: optionFutility, current: currentFutility},
+}
+
+func currentHash(game *gameState) optionValue { return optionValue{spin: game.hashMB()} }
+
+func optionHash(game *gameState, v optionValue) {
+	game.setHash(v.spin)
+	game.println(fmt.Sprintf("transposition table: %v", game.tt))
+}
+
+func optionClearHash(game *gameState, _ optionValue) {
+	if game.tt != nil {
+		game.tt.clear()
+	}
+}
+
+func currentThreads(game *gameState) optionValue { return optionValue{spin: max(game.threads, 1)} }
+
+func optionThreads(game *gameState, v optionValue) {
+	game.threads = v.spin
+}
+
+func currentOwnBook(game *gameState) optionValue { return optionValue{check: game.dumbBook} }
+
+func optionOwnBook(game *gameState, v optionValue) {
+	game.dumbBook = v.check
+}
+
+func optionBookFile(_ *gameState, v optionValue) {
+	if v.str == "" || v.str == "<empty>" {
+		loadBook(bufio.NewReader(strings.NewReader(defaultBook))) // builtin book
+		return
+	}
+	loadBookFromFile(v.str)
+}
+
+func currentAddChildren(game *gameState) optionValue { return optionValue{check: game.addChildren} }
+
+func optionAddChildren(game *gameState, v optionValue) {
+	game.addChildren = v.check
+}
+
+func optionMultiPV(game *gameState, v optionValue) {
+	game.multiPV = v.spin
+}
+
+// optionBookSeed: non-zero seed is applied to book randomness now and
+// again at every ucinewgame, so that book picks repeat across games.
+func optionBookSeed(game *gameState, v optionValue) {
+	game.bookSeed = int64(v.spin)
+	if game.bookSeed != 0 {
+		seedBook(game.bookSeed)
+	}
+}
+
+func currentNullMove(game *gameState) optionValue { return optionValue{check: game.prune.nullMove} }
+
+func optionNullMove(game *gameState, v optionValue) {
+	game.prune.nullMove = v.check
+}
+
+func currentLMR(game *gameState) optionValue { return optionValue{check: game.prune.lmr} }
+
+func optionLMR(game *gameState, v optionValue) {
+	game.prune.lmr = v.check
+}
+
+func currentFutility(game *gameState) optionValue { return optionValue{check: game.prune.futility} }
+
+func optionFutility(game *gameState, v optionValue) {
+	game.prune.futility = v.check
+}
+
+// withGameDefault takes the default from game, reflecting command line flags.
+func (o uciOption) withGameDefault(game *gameState) uciOption {
+	if o.current == nil {
+		return o
+	}
+	v := o.current(game)
+	switch o.kind {
+	case optionSpin:
+		o.defaultValue = strconv.Itoa(v.spin)
+	case optionCheck:
+		o.defaultValue = strconv.FormatBool(v.check)
+	}
+	return o
+}
+
+func (o uciOption) String() string {
+	s := fmt.Sprintf("option name %s type %s", o.name, o.kind)
+	switch o.kind {
+	case optionButton:
+		return s
+	case optionSpin:
+		return fmt.Sprintf("%s default %s min %d max %d", s, o.defaultValue, o.min, o.max)
+	case optionCombo:
+		s = fmt.Sprintf("%s default %s", s, o.defaultValue)
+		for _, v := range o.vars {
+			s += " var " + v
+		}
+		return s
+	}
+	return fmt.Sprintf("%s default %s", s, o.defaultValue)
+}
+
+// parse validates value according to option type.
+func (o uciOption) parse(value string) (optionValue, error) {
+	switch o.kind {
+	case optionSpin:
+		i, errConv := strconv.Atoi(value)
+		if errConv != nil {
+			return optionValue{}, fmt.Errorf("bad spin value: '%s': %v", value, errConv)
+		}
+		if i < o.min || i > o.max {
+			return optionValue{}, fmt.Errorf("spin value %d out of range %d..%d", i, o.min, o.max)
+		}
+		return optionValue{spin: i}, nil
+	case optionCheck:
+		switch strings.ToLower(value) {
+		case "true":
+			return optionValue{check: true}, nil
+		case "false":
+			return optionValue{check: false}, nil
+		}
+		return optionValue{}, fmt.Errorf("bad check value: '%s'", value)
+	case optionCombo:
+		for _, v := range o.vars {
+			if strings.EqualFold(v, value) {
+				return optionValue{str: v}, nil
+			}
+		}
+		return optionValue{}, fmt.Errorf("bad combo value: '%s'", value)
+	}
+	return optionValue{str: value}, nil // string, button
+}
+
+// findOption looks up option by name. option names are case insensitive.
+func findOption(name string) (uciOption, bool) {
+	for _, o := range tableOption {
+		if strings.EqualFold(o.name, name) {
+			return o, true
+		}
+	}
+	return uciOption{}, false
+}
+
+func (game *gameState) setOption(name, value string) error {
+	o, found := findOption(name)
+	if !found {
+		return fmt.Errorf("unsupported option: '%s'", name)
+	}
+	v, errParse := o.parse(value)
+	if errParse != nil {
+		return fmt.Errorf("option %s: %v", o.name, errParse)
+	}
+	o.set(game, v)
+	return nil
+}
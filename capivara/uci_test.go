@@ -0,0 +1,195 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type uciScoreTest struct {
+	score    int
+	expected string
+}
+
+var testUciScoreTable = []uciScoreTest{
+	{0, "cp 0"},
+	{123, "cp 123"},
+	{-50, "cp -50"},
+	{mateIn(1), "mate 1"},
+	{mateIn(3), "mate 2"},
+	{mateIn(9), "mate 5"},
+	{matedIn(0), "mate 0"},
+	{matedIn(2), "mate -1"},
+	{matedIn(4), "mate -2"},
+}
+
+func TestUciScore(t *testing.T) {
+	for _, data := range testUciScoreTable {
+		if s := uciScore(data.score); s != data.expected {
+			t.Errorf("uciScore(%d): got '%s' expected '%s'", data.score, s, data.expected)
+		}
+	}
+}
+
+func TestUciInfo(t *testing.T) {
+	pv := []move{{src: 12, dst: 28}, {src: 52, dst: 36}}
+	info := uciInfo(5, 9, 0, 25, 20000, 2*time.Second, pv)
+	expected := "info depth 5 seldepth 9 score cp 25 nodes 20000 nps 10000 time 2000 pv e2e4 e7e5"
+	if info != expected {
+		t.Errorf("uciInfo: got '%s' expected '%s'", info, expected)
+	}
+
+	info = uciInfo(5, 9, 2, 25, 20000, 2*time.Second, pv)
+	expected = "info depth 5 seldepth 9 multipv 2 score cp 25 nodes 20000 nps 10000 time 2000 pv e2e4 e7e5"
+	if info != expected {
+		t.Errorf("uciInfo multipv: got '%s' expected '%s'", info, expected)
+	}
+
+	info = uciInfo(1, 1, 0, 25, 20, 0, pv)
+	expected = "info depth 1 seldepth 1 score cp 25 nodes 20 nps 20000 time 0 pv e2e4 e7e5"
+	if info != expected {
+		t.Errorf("uciInfo zero elapsed: got '%s' expected '%s'", info, expected)
+	}
+}
+
+func TestParseSetOption(t *testing.T) {
+	name, value := parseSetOption(strings.Fields("setoption name Book File value /tmp/my book.txt"))
+	if name != "Book File" || value != "/tmp/my book.txt" {
+		t.Errorf("parseSetOption: name='%s' value='%s'", name, value)
+	}
+
+	name, value = parseSetOption(strings.Fields("setoption name Clear Hash"))
+	if name != "Clear Hash" || value != "" {
+		t.Errorf("parseSetOption: name='%s' value='%s'", name, value)
+	}
+}
+
+func TestSetOption(t *testing.T) {
+	game := newGame()
+	game.tt = newTranspositionTable(1)
+
+	if err := game.setOption("hash", "2"); err != nil {
+		t.Errorf("Hash: %v", err)
+	}
+	if size := game.tt.sizeMB(); size < 1 || size > 2 {
+		t.Errorf("Hash: table size=%dMB", size)
+	}
+
+	if err := game.setOption("Threads", "4"); err != nil || game.threads != 4 {
+		t.Errorf("Threads: threads=%d err=%v", game.threads, err)
+	}
+	if err := game.setOption("Threads", "0"); err == nil {
+		t.Errorf("Threads: out of range value accepted")
+	}
+
+	game.dumbBook = true
+	if err := game.setOption("OwnBook", "false"); err != nil || game.dumbBook {
+		t.Errorf("OwnBook: dumbBook=%v err=%v", game.dumbBook, err)
+	}
+	if err := game.setOption("OwnBook", "maybe"); err == nil {
+		t.Errorf("OwnBook: bad check value accepted")
+	}
+
+	if err := game.setOption("AddChildren", "true"); err != nil || !game.addChildren {
+		t.Errorf("AddChildren: addChildren=%v err=%v", game.addChildren, err)
+	}
+
+	if err := game.setOption("MultiPV", "3"); err != nil || game.multiPV != 3 {
+		t.Errorf("MultiPV: multiPV=%d err=%v", game.multiPV, err)
+	}
+
+	if err := game.setOption("Clear Hash", ""); err != nil {
+		t.Errorf("Clear Hash: %v", err)
+	}
+
+	if err := game.setOption("NoSuchOption", "1"); err == nil {
+		t.Errorf("unknown option accepted")
+	}
+}
+
+func TestOptionString(t *testing.T) {
+	o := uciOption{name: "Style", kind: optionCombo, defaultValue: "Normal", vars: []string{"Solid", "Normal", "Risky"}}
+	expected := "option name Style type combo default Normal var Solid var Normal var Risky"
+	if s := o.String(); s != expected {
+		t.Errorf("got '%s' expected '%s'", s, expected)
+	}
+	if v, err := o.parse("risky"); err != nil || v.str != "Risky" {
+		t.Errorf("combo parse: value=%v err=%v", v, err)
+	}
+	if _, err := o.parse("wild"); err == nil {
+		t.Errorf("combo parse: bad value accepted")
+	}
+}
+
+// TestOptionGameDefault: options advertise values set from command line.
+func TestOptionGameDefault(t *testing.T) {
+	game := newGame()
+	game.dumbBook = false               // -dumbBook=false
+	game.tt = newTranspositionTable(64) // -hash 64
+	game.threads = 2                    // -threads 2
+	expected := map[string]string{
+		"OwnBook": "option name OwnBook type check default false",
+		"Hash":    "option name Hash type spin default 64 min 1 max 4096",
+		"Threads": "option name Threads type spin default 2 min 1 max 256",
+	}
+	for _, o := range tableOption {
+		e, found := expected[o.name]
+		if !found {
+			continue
+		}
+		if s := o.withGameDefault(&game).String(); s != e {
+			t.Errorf("got '%s' expected '%s'", s, e)
+		}
+	}
+}
+
+func TestNewGame(t *testing.T) {
+	game := newGame()
+	game.loadFromString(builtinBoard)
+	game.tt = newTranspositionTable(1)
+
+	if _, err := game.validatePosition("e2e4 e7e5"); err != nil {
+		t.Fatalf("play: %v", err)
+	}
+	game.tt.store(game.history[len(game.history)-1].zobrist, 1, ttExact, 0, nullMove)
+
+	uciCmdNewGame(&game, []string{"ucinewgame"})
+
+	if len(game.history) != 1 {
+		t.Errorf("history length=%d after ucinewgame", len(game.history))
+	}
+	if game.tt.used.Load() != 0 {
+		t.Errorf("transposition table not cleared: %v", game.tt)
+	}
+}
+
+func TestUciPosition(t *testing.T) {
+	game := newGame()
+	game.loadFromString(builtinBoard)
+
+	table := []struct {
+		position string
+		bad      bool
+		fen      string // expected board, when position is good
+	}{
+		{"position fen 6k1/5ppp/8/8/8/8/5PPP/3R2K1 w - - 0 0", false, "6k1/5ppp/8/8/8/8/5PPP/3R2K1 w - - 0 1"},
+		{"position fen 6k1/5ppp/8/8/8/8/5PPP/3R2K1 w - e6 0 1", true, ""}, // no pawn to capture en passant
+		{"position startpos moves e2e4 e7e5", false, "rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e6 0 2"},
+		{"position startpos moves e2e4 e2e4", true, ""},
+		{"position fen 6k1/5ppp/8/8/8/8/5PPP/3R2K1 w - - 0 1 moves d1d8", false, "3R2k1/5ppp/8/8/8/8/5PPP/6K1 b - - 1 1"},
+	}
+
+	for _, data := range table {
+		uciCmdPosition(&game, strings.Fields(data.position))
+		if bad := game.badPosition != nil; bad != data.bad {
+			t.Errorf("%s: bad=%v expected=%v: %v", data.position, bad, data.bad, game.badPosition)
+			continue
+		}
+		if data.bad {
+			continue
+		}
+		if fen := game.history[len(game.history)-1].fen(); fen != data.fen {
+			t.Errorf("%s: got %s expected %s", data.position, fen, data.fen)
+		}
+	}
+}
@@ -18,7 +18,15 @@ const (
 	// 3fr: 3-fold repetition
 	// qs: quiescence search
 	// pvs: principal variation search
-	features = "uci ab id pst"
+	// nmp: null-move pruning
+	// lmr: late move reductions
+	// fp: futility pruning
+	// smp: lazy smp
+	// see: static exchange evaluation
+	// mbb: magic bitboards
+	// mu: make/unmake move
+	// lmg: legal move generation
+	features = "uci ab id pst z qs 3fr pvs nmp lmr fp smp see mbb mu lmg"
 )
 
 func fullVersion() string {
@@ -0,0 +1,446 @@
+package main
+
+// 0x88 board
+//
+// https://www.chessprogramming.org/0x88
+//
+// alternative board representation for perft, selected by flag
+// -moveGen=0x88, so that it can be checked against the bitboard board
+// and compared with perft benchmarks. see 0x88.md.
+//
+// the board has 128 squares (index = row*16 + col), only half of them
+// real: any step leaving the board sets a bit in 0x88, so a single test
+// bounds every ray walk. board88 keeps its own pieces, castling rights
+// and en passant target, with its own move generation, attack detection
+// and make/unmake. it is converted from board only once, at perft root.
+// search always uses the bitboard board.
+
+type square88 int
+
+func to88(loc location) square88 {
+	return square88(loc + loc&^7) // row*16 + col
+}
+
+func (s square88) loc() location {
+	return location((s + s&7) >> 1) // row*8 + col
+}
+
+func (s square88) offBoard() bool {
+	return s&0x88 != 0
+}
+
+func (s square88) row() int {
+	return int(s >> 4)
+}
+
+var (
+	knight88      = [8]square88{33, 31, 18, 14, -14, -18, -31, -33}
+	king88        = [8]square88{16, 17, 1, -15, -16, -17, -1, 15}
+	bishop88      = [4]square88{17, -15, -17, 15}
+	rook88        = [4]square88{16, 1, -16, -1}
+	pawnCapture88 = [2][2]square88{{15, 17}, {-17, -15}} // color => capture steps
+)
+
+type board88 struct {
+	square  [128]piece // squares off board stay empty
+	king    [2]square88
+	flags   [2]colorFlag
+	passant square88 // en passant target square, 0 for none: a1 is never a target
+	turn    pieceColor
+}
+
+func newBoard88(b *board) board88 {
+	var b88 board88
+	for loc := location(0); loc < 64; loc++ {
+		b88.square[to88(loc)] = b.square[loc]
+	}
+	b88.king[colorWhite] = to88(b.king[colorWhite])
+	b88.king[colorBlack] = to88(b.king[colorBlack])
+	b88.flags = b.flags
+	if target, ok := b.passantTarget(); ok {
+		b88.passant = to88(target)
+	}
+	b88.turn = b.turn
+	return b88
+}
+
+type move88 struct {
+	src       square88
+	dst       square88
+	promotion piece
+}
+
+func (m move88) move() move {
+	return move{src: m.src.loc(), dst: m.dst.loc(), promotion: m.promotion}
+}
+
+// movePool88 is a stack of move lists, like movePool.
+type movePool88 struct {
+	pool []move88
+}
+
+func newMovePool88() *movePool88 {
+	return &movePool88{pool: make([]move88, 0, 1000)}
+}
+
+func (mp *movePool88) push(m move88) {
+	mp.pool = append(mp.pool, m)
+}
+
+func (mp *movePool88) drop(n int) {
+	mp.pool = mp.pool[:len(mp.pool)-n]
+}
+
+// last returns the top n moves.
+func (mp *movePool88) last(n int) []move88 {
+	return mp.pool[len(mp.pool)-n:]
+}
+
+// perft0x88 converts b to board88 and counts like perft.
+func perft0x88(b board, depth int, divide perftDivide) (int64, int64) {
+	b88 := newBoard88(&b)
+	return b88.perft(depth, newMovePool88(), divide)
+}
+
+func (b *board88) perft(depth int, moves *movePool88, divide perftDivide) (int64, int64) {
+	if depth < 1 {
+		return 0, 0
+	}
+	countMoves := b.generateMoves(moves)
+	total := int64(countMoves)
+	if depth == 1 && divide == nil {
+		moves.drop(countMoves)
+		return total, total
+	}
+	var nodes int64
+	for _, m := range moves.last(countMoves) {
+		u := b.makeMove(m)
+		n, t := b.perft(depth-1, moves, nil)
+		b.unmakeMove(m, u)
+		if depth == 1 {
+			n = 1 // leaf
+		}
+		if divide != nil {
+			divide(m.move(), n, t)
+		}
+		nodes += n
+		total += t
+	}
+	moves.drop(countMoves)
+	return nodes, total
+}
+
+// generateMoves records legal moves for side to move, generating
+// pseudo-legal moves and dropping those leaving own king attacked.
+// returns number of moves.
+func (b *board88) generateMoves(moves *movePool88) int {
+	first := len(moves.pool)
+
+	b.generatePieces(moves)
+	b.generateCastling(moves)
+
+	// keep legal moves
+	color := b.turn
+	them := colorInverse(color)
+	legal := first
+	for _, m := range moves.pool[first:] {
+		u := b.makeMove(m)
+		if !b.attacked(b.king[color], them) {
+			moves.pool[legal] = m
+			legal++
+		}
+		b.unmakeMove(m, u)
+	}
+	moves.pool = moves.pool[:legal]
+
+	return legal - first
+}
+
+// generatePieces records pseudo-legal moves for every piece of side to move,
+// except castling.
+func (b *board88) generatePieces(moves *movePool88) {
+	for s := square88(0); s < 128; s++ {
+		if s.offBoard() {
+			s += 7 // skip to next row
+			continue
+		}
+		p := b.square[s]
+		if p == pieceNone || p.color() != b.turn {
+			continue
+		}
+
+		switch p.kind() {
+		case whitePawn:
+			b.generatePawn(moves, s)
+		case whiteKnight:
+			b.generateLeaper(moves, s, knight88[:])
+		case whiteKing:
+			b.generateLeaper(moves, s, king88[:])
+		case whiteBishop:
+			b.generateSliding(moves, s, bishop88[:])
+		case whiteRook:
+			b.generateSliding(moves, s, rook88[:])
+		case whiteQueen:
+			b.generateSliding(moves, s, bishop88[:])
+			b.generateSliding(moves, s, rook88[:])
+		}
+	}
+}
+
+func (b *board88) generateLeaper(moves *movePool88, from square88, steps []square88) {
+	for _, step := range steps {
+		to := from + step
+		if to.offBoard() {
+			continue
+		}
+		if p := b.square[to]; p == pieceNone || p.color() != b.turn {
+			moves.push(move88{src: from, dst: to})
+		}
+	}
+}
+
+func (b *board88) generateSliding(moves *movePool88, from square88, directions []square88) {
+	for _, dir := range directions {
+		for to := from + dir; !to.offBoard(); to += dir {
+			p := b.square[to]
+			if p == pieceNone {
+				moves.push(move88{src: from, dst: to})
+				continue
+			}
+			if p.color() != b.turn {
+				moves.push(move88{src: from, dst: to}) // capture
+			}
+			break
+		}
+	}
+}
+
+func (b *board88) generatePawn(moves *movePool88, from square88) {
+	color := b.turn
+	forward := square88(16 * colorToSignal(color))  // 0=>16 1=>-16
+	firstRow := 7*int(color) + colorToSignal(color) // 0=>1 1=>6
+
+	// can move one up/down?
+	if to := from + forward; !to.offBoard() && b.square[to] == pieceNone {
+		recordPawnMove88(moves, color, from, to)
+
+		// can move two up/down?
+		if from.row() == firstRow {
+			if to2 := to + forward; b.square[to2] == pieceNone {
+				moves.push(move88{src: from, dst: to2})
+			}
+		}
+	}
+
+	// captures, en passant included
+	for _, step := range pawnCapture88[color] {
+		to := from + step
+		if to.offBoard() {
+			continue
+		}
+		if p := b.square[to]; p != pieceNone && p.color() != color {
+			recordPawnMove88(moves, color, from, to)
+			continue
+		}
+		if b.passant != 0 && to == b.passant {
+			moves.push(move88{src: from, dst: to})
+		}
+	}
+}
+
+// recordPawnMove88 records pawn move, expanding promotions on last row.
+func recordPawnMove88(moves *movePool88, color pieceColor, src, dst square88) {
+	if dst.row() != 7-7*int(color) { // 0=>7 1=>0
+		moves.push(move88{src: src, dst: dst})
+		return
+	}
+	c := piece(color << 3)
+	moves.push(move88{src: src, dst: dst, promotion: c + whiteQueen})
+	moves.push(move88{src: src, dst: dst, promotion: c + whiteRook})
+	moves.push(move88{src: src, dst: dst, promotion: c + whiteBishop})
+	moves.push(move88{src: src, dst: dst, promotion: c + whiteKnight})
+}
+
+// generateCastling records castling moves. the king must not be
+// in check, nor pass through or land on attacked squares.
+func (b *board88) generateCastling(moves *movePool88) {
+	color := b.turn
+	them := colorInverse(color)
+	colE := square88(0x70*int(color)) + 4 // king: e1 or e8
+
+	if b.flags[color]&lostCastlingLeft == 0 {
+		if b.square[colE-1] == pieceNone && b.square[colE-2] == pieceNone && b.square[colE-3] == pieceNone {
+			// squares are free
+			if !b.attacked(colE, them) && !b.attacked(colE-1, them) && !b.attacked(colE-2, them) {
+				moves.push(move88{src: colE, dst: colE - 2})
+			}
+		}
+	}
+	if b.flags[color]&lostCastlingRight == 0 {
+		if b.square[colE+1] == pieceNone && b.square[colE+2] == pieceNone {
+			// squares are free
+			if !b.attacked(colE, them) && !b.attacked(colE+1, them) && !b.attacked(colE+2, them) {
+				moves.push(move88{src: colE, dst: colE + 2})
+			}
+		}
+	}
+}
+
+// attacked reports whether any piece of color attacks trg.
+func (b *board88) attacked(trg square88, color pieceColor) bool {
+	c := piece(color << 3)
+
+	// pawn: attacking pawns stand one capture step behind trg
+
+	for _, step := range pawnCapture88[color] {
+		if s := trg - step; !s.offBoard() && b.square[s] == c+whitePawn {
+			return true
+		}
+	}
+
+	// knight
+
+	for _, step := range knight88 {
+		if s := trg + step; !s.offBoard() && b.square[s] == c+whiteKnight {
+			return true
+		}
+	}
+
+	// king
+
+	for _, step := range king88 {
+		if s := trg + step; !s.offBoard() && b.square[s] == c+whiteKing {
+			return true
+		}
+	}
+
+	// bishop or queen
+
+	if b.slidingAttack(trg, bishop88[:], c+whiteBishop, c+whiteQueen) {
+		return true
+	}
+
+	// rook or queen
+
+	return b.slidingAttack(trg, rook88[:], c+whiteRook, c+whiteQueen)
+}
+
+// slidingAttack looks for slider p1 or p2 as first piece along directions from trg.
+func (b *board88) slidingAttack(trg square88, directions []square88, p1, p2 piece) bool {
+	for _, dir := range directions {
+		for s := trg + dir; !s.offBoard(); s += dir {
+			p := b.square[s]
+			if p == pieceNone {
+				continue
+			}
+			if p == p1 || p == p2 {
+				return true
+			}
+			break
+		}
+	}
+	return false
+}
+
+// undo88 holds what makeMove changed beyond moving pieces.
+type undo88 struct {
+	captured piece        // pieceNone for quiet moves
+	flags    [2]colorFlag // castling flags before the move
+	passant  square88     // en passant target square before the move
+}
+
+// makeMove plays m on b, returning undo data for unmakeMove.
+func (b *board88) makeMove(m move88) undo88 {
+	u := undo88{captured: b.square[m.dst], flags: b.flags, passant: b.passant}
+
+	p := b.square[m.src]
+	color := p.color()
+	b.square[m.src] = pieceNone
+	b.passant = 0 // en passant vanishes
+
+	switch p.kind() {
+	case whitePawn:
+		forward := square88(16 * colorToSignal(color))
+		switch {
+		case m.promotion != pieceNone:
+			p = piece(color<<3) + m.promotion.kind()
+		case u.passant != 0 && m.dst == u.passant:
+			u.captured = b.square[m.dst-forward] // captured passant pawn
+			b.square[m.dst-forward] = pieceNone
+		case m.dst-m.src == 2*forward:
+			b.passant = m.src + forward // square the pawn skipped
+		}
+	case whiteKing:
+		b.king[color] = m.dst
+		b.flags[color] |= lostCastlingLeft | lostCastlingRight
+		if rookSrc, rookDst, castling := castlingRook88(m); castling {
+			b.square[rookDst] = b.square[rookSrc]
+			b.square[rookSrc] = pieceNone
+		}
+	}
+
+	// a rook leaving or captured on its corner loses castling
+	b.touchCorner(m.src)
+	b.touchCorner(m.dst)
+
+	b.square[m.dst] = p
+	b.turn = colorInverse(b.turn)
+
+	return u
+}
+
+// unmakeMove takes back m, previously played on b by makeMove returning u.
+func (b *board88) unmakeMove(m move88, u undo88) {
+	b.turn = colorInverse(b.turn)
+	color := b.turn
+
+	p := b.square[m.dst]
+	if m.promotion != pieceNone {
+		p = piece(color<<3) + whitePawn
+	}
+	b.square[m.src] = p
+	b.square[m.dst] = u.captured
+
+	switch p.kind() {
+	case whitePawn:
+		if u.passant != 0 && m.dst == u.passant {
+			b.square[m.dst] = pieceNone
+			b.square[m.dst-square88(16*colorToSignal(color))] = u.captured // captured passant pawn
+		}
+	case whiteKing:
+		b.king[color] = m.src
+		if rookSrc, rookDst, castling := castlingRook88(m); castling {
+			b.square[rookSrc] = b.square[rookDst]
+			b.square[rookDst] = pieceNone
+		}
+	}
+
+	b.flags = u.flags
+	b.passant = u.passant
+}
+
+// castlingRook88 returns rook source and destination when king move m castles.
+func castlingRook88(m move88) (square88, square88, bool) {
+	row := m.src &^ 7
+	switch m.dst - m.src {
+	case -2:
+		return row, row + 3, true // A => D
+	case 2:
+		return row + 7, row + 5, true // H => F
+	}
+	return 0, 0, false
+}
+
+// touchCorner drops castling rights tied to rook corner s.
+func (b *board88) touchCorner(s square88) {
+	switch s {
+	case 0x00: // a1
+		b.flags[colorWhite] |= lostCastlingLeft
+	case 0x07: // h1
+		b.flags[colorWhite] |= lostCastlingRight
+	case 0x70: // a8
+		b.flags[colorBlack] |= lostCastlingLeft
+	case 0x77: // h8
+		b.flags[colorBlack] |= lostCastlingRight
+	}
+}
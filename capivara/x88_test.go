@@ -0,0 +1,61 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSquare0x88(t *testing.T) {
+	for loc := location(0); loc < 64; loc++ {
+		s := to88(loc)
+		if s.offBoard() {
+			t.Errorf("%s: 0x88 square %d off board", locToStr(loc), s)
+		}
+		if back := s.loc(); back != loc {
+			t.Errorf("%s: 0x88 square %d converts back to %s", locToStr(loc), s, locToStr(back))
+		}
+	}
+
+	h8 := to88(63)
+	if h8 != 0x77 {
+		t.Errorf("h8: got 0x88 square 0x%x expected 0x77", int(h8))
+	}
+	for _, step := range king88 {
+		if s := h8 + step; !s.offBoard() && s.loc()/8 < 6 {
+			t.Errorf("h8 step %d wrapped to %s", step, locToStr(s.loc()))
+		}
+	}
+}
+
+func TestParseMoveGenerator(t *testing.T) {
+	for _, g := range moveGenerators {
+		if parsed, err := parseMoveGenerator(g.String()); err != nil || parsed.name != g.name {
+			t.Errorf("%v: parsed=%v err=%v", g, parsed, err)
+		}
+	}
+	if _, err := parseMoveGenerator("mailbox"); err == nil {
+		t.Errorf("bad generator accepted")
+	}
+}
+
+// TestMakeUnmake0x88 checks the 0x88 board is restored exactly after every move.
+func TestMakeUnmake0x88(t *testing.T) {
+	for _, data := range perftFENTestTable {
+		b, err := fenParse(strings.Fields(data.fen))
+		if err != nil {
+			t.Fatalf("%s: fen: %v", data.fen, err)
+		}
+		b88 := newBoard88(&b)
+		before := b88
+
+		moves := newMovePool88()
+		for _, m := range moves.last(b88.generateMoves(moves)) {
+			u := b88.makeMove(m)
+			b88.unmakeMove(m, u)
+			if b88 != before {
+				t.Errorf("%s: move %s: board not restored", data.name, m.move())
+				b88 = before
+			}
+		}
+	}
+}
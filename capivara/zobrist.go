@@ -0,0 +1,129 @@
+package main
+
+// zobrist hashing
+//
+// https://www.chessprogramming.org/Zobrist_Hashing
+//
+// board.zobrist is updated incrementally as pieces enter and leave
+// the board, as the turn switches, as castling rights are lost
+// and as en passant becomes available or vanishes.
+//
+// an empty board with white to move and all castling rights
+// (the zero value for board) has key 0.
+//
+// en passant is hashed only when an opponent pawn stands ready to capture,
+// so a double pawn push nobody can take transposes into the same key.
+// keys come from our own random table: they cannot probe Polyglot books.
+
+type zobristTable struct {
+	piece     [2][6][64]uint64 // color => piece kind => location => key
+	castling  [2][4]uint64     // color => flags (lostCastlingLeft|lostCastlingRight) => key
+	passant   [8]uint64        // en passant target column => key
+	blackTurn uint64
+}
+
+// zobristSeed is fixed so that keys are stable across runs
+const zobristSeed = 0x43617069766172 // "Capivar"
+
+var zobrist = newZobristTable(zobristSeed)
+
+func newZobristTable(seed uint64) *zobristTable {
+	z := &zobristTable{}
+	r := splitMix64{state: seed}
+	for c := 0; c < 2; c++ {
+		for k := 0; k < 6; k++ {
+			for loc := 0; loc < 64; loc++ {
+				z.piece[c][k][loc] = r.next()
+			}
+		}
+	}
+	for c := 0; c < 2; c++ {
+		left := r.next()
+		right := r.next()
+		z.castling[c][lostCastlingLeft] = left
+		z.castling[c][lostCastlingRight] = right
+		z.castling[c][lostCastlingLeft|lostCastlingRight] = left ^ right
+	}
+	for col := 0; col < 8; col++ {
+		z.passant[col] = r.next()
+	}
+	z.blackTurn = r.next()
+	return z
+}
+
+// splitMix64 is a tiny deterministic generator used to fill zobrist tables.
+type splitMix64 struct {
+	state uint64
+}
+
+func (s *splitMix64) next() uint64 {
+	s.state += 0x9e3779b97f4a7c15
+	z := s.state
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+func (z *zobristTable) pieceKey(loc location, p piece) uint64 {
+	if p == pieceNone {
+		return 0 // castling may be attempted on boards missing the rook
+	}
+	return z.piece[p.color()][p.kind()-1][loc]
+}
+
+// zobristPassant returns the key contribution of the current en passant target,
+// if some pawn can capture on it.
+func (b *board) zobristPassant() uint64 {
+	target, ok := b.passantTarget()
+	if !ok {
+		return 0
+	}
+	// target on row 3 means white pushed: black captures, and vice versa
+	capturer := colorWhite
+	if target/8 == 2 {
+		capturer = colorBlack
+	}
+	// capturing pawns stand where a pawn of the pushing side on target would attack
+	if attack.pawn[colorInverse(capturer)][target]&b.bbColor[capturer]&b.bbKind[whitePawn] == 0 {
+		return 0
+	}
+	return zobrist.passant[target%8]
+}
+
+// zobristHash computes the key from scratch.
+// it is used for positions loaded from outside and for verification.
+func (b *board) zobristHash() uint64 {
+	var key uint64
+	for loc := location(0); loc < 64; loc++ {
+		if p := b.square[loc]; p != pieceNone {
+			key ^= zobrist.pieceKey(loc, p)
+		}
+	}
+	key ^= zobrist.castling[colorWhite][b.flags[colorWhite]]
+	key ^= zobrist.castling[colorBlack][b.flags[colorBlack]]
+	key ^= b.zobristPassant()
+	if b.turn == colorBlack {
+		key ^= zobrist.blackTurn
+	}
+	return key
+}
+
+func (b *board) switchTurn() {
+	b.turn = colorInverse(b.turn)
+	b.zobrist ^= zobrist.blackTurn
+}
+
+func (b *board) setPassant(target location) {
+	b.zobrist ^= b.zobristPassant()
+	b.passant = target
+	b.zobrist ^= b.zobristPassant()
+}
+
+func (b *board) setFlags(color pieceColor, flags colorFlag) {
+	b.zobrist ^= zobrist.castling[color][b.flags[color]] ^ zobrist.castling[color][flags]
+	b.flags[color] = flags
+}
+
+func (b *board) loseCastling(color pieceColor, lost colorFlag) {
+	b.setFlags(color, b.flags[color]|lost)
+}
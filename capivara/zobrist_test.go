@@ -0,0 +1,99 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestZobristIncremental(t *testing.T) {
+	for _, data := range perftFENTestTable {
+		game := newGame()
+		game.loadFromFen(strings.Fields(data.fen))
+		b := game.history[len(game.history)-1]
+
+		if b.zobrist != b.zobristHash() {
+			t.Errorf("%s: root key=%016x expected=%016x", data.name, b.zobrist, b.zobristHash())
+		}
+
+		buf := defaultBoardPool
+		buf.reset()
+		zobristWalk(t, data.name, b, 3, buf)
+	}
+}
+
+func zobristWalk(t *testing.T, name string, b board, depth int, buf *boardPool) {
+	if depth < 1 {
+		return
+	}
+	countChildren := b.generateChildren(buf)
+	lastChildren := buf.pool[len(buf.pool)-countChildren:]
+	for _, c := range lastChildren {
+		if expected := c.zobristHash(); c.zobrist != expected {
+			t.Errorf("%s: move=%s key=%016x expected=%016x", name, c.lastMove, c.zobrist, expected)
+			continue
+		}
+		zobristWalk(t, name, c, depth-1, buf)
+	}
+	buf.drop(countChildren)
+}
+
+func TestZobristTransposition(t *testing.T) {
+	g1 := newGame()
+	g1.loadFromString(builtinBoard)
+	if _, err := g1.validatePosition("g1f3 g8f6 b1c3 b8c6"); err != nil {
+		t.Fatalf("g1: %v", err)
+	}
+
+	g2 := newGame()
+	g2.loadFromString(builtinBoard)
+	if _, err := g2.validatePosition("b1c3 b8c6 g1f3 g8f6"); err != nil {
+		t.Fatalf("g2: %v", err)
+	}
+
+	k1 := g1.history[len(g1.history)-1].zobrist
+	k2 := g2.history[len(g2.history)-1].zobrist
+	if k1 != k2 {
+		t.Errorf("transposition keys differ: %016x %016x", k1, k2)
+	}
+
+	// same pieces, different side to move
+	g3 := newGame()
+	g3.loadFromString(builtinBoard)
+	if _, err := g3.validatePosition("g1f3 g8f6 f3g1"); err != nil {
+		t.Fatalf("g3: %v", err)
+	}
+	k3 := g3.history[len(g3.history)-1].zobrist
+	k0 := g3.history[0].zobrist
+	if k3 == k0 {
+		t.Errorf("turn should change key: %016x", k3)
+	}
+}
+
+// TestZobristPassant: en passant changes the key only when a capture is possible.
+func TestZobristPassant(t *testing.T) {
+	table := []struct {
+		with    string
+		without string
+		same    bool
+	}{
+		{"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1", "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1", true},
+		{"4k3/8/8/8/3pP3/8/8/4K3 b - e3 0 1", "4k3/8/8/8/3pP3/8/8/4K3 b - - 0 1", false},
+		{"4k3/8/8/3Pp3/8/8/8/4K3 w - e6 0 1", "4k3/8/8/3Pp3/8/8/8/4K3 w - - 0 1", false},
+	}
+	for _, data := range table {
+		with, err := fenParse(strings.Fields(data.with))
+		if err != nil {
+			t.Fatalf("%s: %v", data.with, err)
+		}
+		without, err := fenParse(strings.Fields(data.without))
+		if err != nil {
+			t.Fatalf("%s: %v", data.without, err)
+		}
+		if same := with.zobrist == without.zobrist; same != data.same {
+			t.Errorf("%s: same key=%v expected=%v", data.with, same, data.same)
+		}
+		if with.zobrist != with.zobristHash() {
+			t.Errorf("%s: incremental=%016x scratch=%016x", data.with, with.zobrist, with.zobristHash())
+		}
+	}
+}